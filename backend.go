@@ -2,7 +2,9 @@ package kms
 
 import (
 	"context"
+	"errors"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -22,6 +24,22 @@ func Factory(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 // user wallet.
 type kmsBackend struct {
 	*framework.Backend
+
+	// lock serializes updates of the HD seed derivation indexes.
+	lock sync.Mutex
+
+	// usageLock serializes the check and update of velocity limit usage.
+	usageLock sync.Mutex
+
+	// signRequestLock serializes the approvals of sign requests.
+	signRequestLock sync.Mutex
+
+	// ledgerLock serializes the appends to the signing ledgers.
+	ledgerLock sync.Mutex
+
+	configLock sync.RWMutex
+	config     *kmsConfig
+	keyCache   *keyCache
 }
 
 // backend defines the target API backend
@@ -37,26 +55,55 @@ func backend() *kmsBackend {
 			SealWrapStorage: []string{
 				"did",
 				"wallet",
+				"seed",
+				"deleted",
 			},
 		},
 		Paths: framework.PathAppend(
+			pathConfig(&b),
 			pathWallet(&b),
+			pathSeed(&b),
+			pathWalletRotate(&b),
+			pathWalletDeleted(&b),
+			pathPolicy(&b),
+			pathLimit(&b),
 			pathSign(&b),
+			pathSignTypedData(&b),
+			pathSignMessage(&b),
+			pathSignBatch(&b),
+			pathSignPsbt(&b),
+			pathSignRequest(&b),
+			pathLedger(&b),
+			pathVerify(&b),
+			pathRecover(&b),
 		),
-		Secrets:     []*framework.Secret{},
-		BackendType: logical.TypeLogical,
-		Invalidate:  b.invalidate,
+		Secrets:      []*framework.Secret{},
+		BackendType:  logical.TypeLogical,
+		Invalidate:   b.invalidate,
+		PeriodicFunc: b.periodicFunc,
 	}
 	return &b
 }
 
 // invalidate clears an existing configuration in the backend
 func (b *kmsBackend) invalidate(ctx context.Context, key string) {
-	if key == "config" {
-		// b.reset()
+	switch {
+	case key == configStoragePath:
+		b.resetConfig()
+	case strings.HasPrefix(key, walletStoragePath+"/"):
+		b.getKeyCache().remove(key)
 	}
 }
 
+// periodicFunc destroys the deleted wallets past their retention window and
+// deletes the sign requests long expired.
+func (b *kmsBackend) periodicFunc(ctx context.Context, req *logical.Request) error {
+	return errors.Join(
+		b.purgeDeletedWallets(ctx, req.Storage),
+		b.purgeSignRequests(ctx, req.Storage),
+	)
+}
+
 // backendHelp should contain help information for the backend
 const backendHelp = `
 The KMS secrets backend generates user wallet.
@@ -1,29 +1,145 @@
 package chains
 
 import (
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"math/big"
 
 	"github.com/btcsuite/btcutil/base58"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 )
 
 const (
 	AergoAddressVersion = 0x42
 )
 
+// AergoTxTypes maps the names of the Aergo transaction types to their values.
+var AergoTxTypes = map[string]int32{
+	"NORMAL":        0,
+	"GOVERNANCE":    1,
+	"REDEPLOY":      2,
+	"FEEDELEGATION": 3,
+	"TRANSFER":      4,
+	"CALL":          5,
+	"DEPLOY":        6,
+}
+
+// aergoSpecialAccounts are the system accounts addressed by name.
+var aergoSpecialAccounts = map[string]bool{
+	"aergo.system":     true,
+	"aergo.name":       true,
+	"aergo.enterprise": true,
+	"aergo.vault":      true,
+}
+
 type AergoChain BaseChain
 
+// AergoTxBody holds the fields of an Aergo transaction body
+// covered by its hash.
+type AergoTxBody struct {
+	Nonce       uint64
+	Account     []byte
+	Recipient   []byte
+	Amount      *big.Int
+	Payload     []byte
+	GasLimit    uint64
+	GasPrice    *big.Int
+	Type        int32
+	ChainIdHash []byte
+	Sign        []byte
+}
+
 func (c AergoChain) GetPrivateKeySerialized() []byte {
 	return c.PrivateKey.Serialize()
 }
 
 func (c AergoChain) GetPublicKeySerialized() []byte {
-	return c.PrivateKey.PubKey().SerializeCompressed()
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c AergoChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeCompressed()
 }
 
 func (c AergoChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
 	return base58.CheckEncode(pubKeySerialized, AergoAddressVersion)
 }
 
+// SignCompact returns the DER-encoded ECDSA signature Aergo expects.
 func (c AergoChain) SignCompact(msgHash []byte) (string, error) {
-	return "", fmt.Errorf("aergo sign not supported")
+	signature := ecdsa.Sign(c.PrivateKey, msgHash)
+
+	base64Sign := b64.StdEncoding.EncodeToString(signature.Serialize())
+	return base64Sign, nil
+}
+
+func (c AergoChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	if pubKey == nil {
+		return nil, fmt.Errorf("missing public key to verify aergo signature")
+	}
+
+	sig, err := ecdsa.ParseDERSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sig.Verify(msgHash, pubKey) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	return pubKey, nil
+}
+
+// DecodeAergoAddress returns the raw bytes of an Aergo address.
+func DecodeAergoAddress(address string) ([]byte, error) {
+	if address == "" {
+		return nil, nil
+	}
+	if aergoSpecialAccounts[address] {
+		return []byte(address), nil
+	}
+
+	decoded, version, err := base58.CheckDecode(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aergo address %v: %w", address, err)
+	}
+	if version != AergoAddressVersion {
+		return nil, fmt.Errorf("invalid aergo address version: %v", version)
+	}
+	return decoded, nil
+}
+
+// AergoAddressPublicKey returns the public key encoded in an Aergo address.
+func AergoAddressPublicKey(address string) (*secp256k1.PublicKey, error) {
+	decoded, err := DecodeAergoAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return secp256k1.ParsePubKey(decoded)
+}
+
+// Hash returns the Aergo transaction hash of the body.
+// The hash to sign is computed while Sign is empty.
+func (tx *AergoTxBody) Hash() []byte {
+	digest := sha256.New()
+	digest.Write(binary.LittleEndian.AppendUint64(nil, tx.Nonce))
+	digest.Write(tx.Account)
+	digest.Write(tx.Recipient)
+	digest.Write(aergoBigIntBytes(tx.Amount))
+	digest.Write(tx.Payload)
+	digest.Write(binary.LittleEndian.AppendUint64(nil, tx.GasLimit))
+	digest.Write(aergoBigIntBytes(tx.GasPrice))
+	digest.Write(binary.LittleEndian.AppendUint32(nil, uint32(tx.Type)))
+	digest.Write(tx.ChainIdHash)
+	digest.Write(tx.Sign)
+	return digest.Sum(nil)
+}
+
+func aergoBigIntBytes(n *big.Int) []byte {
+	if n == nil {
+		return nil
+	}
+	return n.Bytes()
 }
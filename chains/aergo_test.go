@@ -0,0 +1,57 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAergoTxSignAndVerify(t *testing.T) {
+	privKeyBytes, _ := hex.DecodeString("83e992df7015dcc946ab9b404b65e2a786913761e9d09f45675e9dccd1a47a2e")
+	chain := AergoChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	address := chain.GetPublicKeyAddress(chain.GetPublicKeySerialized())
+	require.Equal(t, "AmN5kDEYAxUzFvjX6541AVrPkzeg2H4Qxc79ssBWUpqNLbur9M36", address)
+
+	account, err := DecodeAergoAddress(address)
+	require.NoError(t, err)
+	require.Equal(t, chain.GetPublicKeySerialized(), account)
+
+	recipient, err := DecodeAergoAddress("aergo.system")
+	require.NoError(t, err)
+	require.Equal(t, []byte("aergo.system"), recipient)
+
+	tx := &AergoTxBody{
+		Nonce:       1,
+		Account:     account,
+		Recipient:   recipient,
+		Amount:      big.NewInt(1000000000000000000),
+		GasLimit:    100000,
+		GasPrice:    big.NewInt(50000000000),
+		Type:        AergoTxTypes["GOVERNANCE"],
+		ChainIdHash: make([]byte, 32),
+	}
+	txHash := tx.Hash()
+	require.Len(t, txHash, 32)
+
+	signature, err := chain.SignCompact(txHash)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x30), sigBytes[0], "expected DER sequence")
+
+	pubKey, err := AergoAddressPublicKey(address)
+	require.NoError(t, err)
+
+	_, err = chain.VerifySignature(txHash, sigBytes, pubKey)
+	require.NoError(t, err)
+
+	tx.Nonce = 2
+	_, err = chain.VerifySignature(tx.Hash(), sigBytes, pubKey)
+	require.ErrorContains(t, err, "invalid signature")
+}
@@ -2,6 +2,7 @@ package chains
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 )
@@ -9,22 +10,53 @@ import (
 type ChainName string
 
 const (
-	AERGO ChainName = "aergo"
-	ICON  ChainName = "icon"
-	ETHER ChainName = "ether"
+	AERGO   ChainName = "aergo"
+	ICON    ChainName = "icon"
+	ETHER   ChainName = "ether"
+	BITCOIN ChainName = "bitcoin"
+	COSMOS  ChainName = "cosmos"
+	TRON    ChainName = "tron"
+	SOLANA  ChainName = "solana"
+	KAIA    ChainName = "kaia"
 )
 
+// coinTypes are the BIP-44 coin types registered in SLIP-0044.
+var coinTypes = map[ChainName]uint32{
+	BITCOIN: 0,
+	ETHER:   60,
+	COSMOS:  118,
+	ICON:    74,
+	TRON:    195,
+	AERGO:   441,
+	SOLANA:  501,
+	KAIA:    8217,
+}
+
+// Chain is a chain signing with secp256k1 keys.
 type Chain interface {
-	GetPrivateKeySerialized() []byte
-	GetPublicKeySerialized() []byte
-	GetPublicKeyAddress(b []byte) string
-	SignCompact(msgHash []byte) (string, error)
+	Signer
+
+	// SerializePublicKey serializes the public key as the chain derives addresses from it.
+	SerializePublicKey(pubKey *secp256k1.PublicKey) []byte
+	// VerifySignature returns the public key which produced the signature of msgHash.
+	// Recoverable signatures ignore pubKey, the others are verified against it.
+	VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error)
 }
 
 type BaseChain struct {
 	PrivateKey *secp256k1.PrivateKey
 }
 
+// ChainNames returns the names of the supported chains in sorted order.
+func ChainNames() []ChainName {
+	names := make([]ChainName, 0, len(coinTypes))
+	for name := range coinTypes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
 func NewChain(chainName ChainName, privateKey *secp256k1.PrivateKey) (Chain, error) {
 	switch chainName {
 	case ICON:
@@ -33,6 +65,31 @@ func NewChain(chainName ChainName, privateKey *secp256k1.PrivateKey) (Chain, err
 		return AergoChain{PrivateKey: privateKey}, nil
 	case ETHER:
 		return EtherChain{PrivateKey: privateKey}, nil
+	case BITCOIN:
+		return BitcoinChain{PrivateKey: privateKey}, nil
+	case COSMOS:
+		return CosmosChain{PrivateKey: privateKey}, nil
+	case TRON:
+		return TronChain{PrivateKey: privateKey}, nil
+	case KAIA:
+		return KaiaChain{PrivateKey: privateKey}, nil
 	}
 	return nil, fmt.Errorf("unknown chain name: %v", chainName)
 }
+
+// CoinType returns the BIP-44 coin type of the chain.
+func CoinType(chainName ChainName) (uint32, error) {
+	if coinType, ok := coinTypes[chainName]; ok {
+		return coinType, nil
+	}
+	return 0, fmt.Errorf("unknown chain name: %v", chainName)
+}
+
+// PublicKeyAddress returns the address of the public key on the chain.
+func PublicKeyAddress(chainName ChainName, pubKey *secp256k1.PublicKey) (string, error) {
+	chain, err := NewChain(chainName, nil)
+	if err != nil {
+		return "", err
+	}
+	return chain.GetPublicKeyAddress(chain.SerializePublicKey(pubKey)), nil
+}
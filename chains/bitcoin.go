@@ -0,0 +1,291 @@
+package chains
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	BitcoinMainnet = "mainnet"
+	BitcoinTestnet = "testnet"
+	BitcoinRegtest = "regtest"
+
+	// BitcoinP2PKH is the legacy pay-to-pubkey-hash address.
+	BitcoinP2PKH = "p2pkh"
+	// BitcoinP2WPKH is the native SegWit v0 pay-to-witness-pubkey-hash address.
+	BitcoinP2WPKH = "p2wpkh"
+	// BitcoinP2TR is the Taproot (SegWit v1) key-path address of BIP-86.
+	BitcoinP2TR = "p2tr"
+
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// BitcoinNetwork holds the address parameters of a bitcoin network.
+type BitcoinNetwork struct {
+	Name             string
+	PubKeyHashAddrID byte
+	ScriptHashAddrID byte
+	Bech32HRP        string
+}
+
+var bitcoinNetworks = map[string]*BitcoinNetwork{
+	BitcoinMainnet: {Name: BitcoinMainnet, PubKeyHashAddrID: 0x00, ScriptHashAddrID: 0x05, Bech32HRP: "bc"},
+	BitcoinTestnet: {Name: BitcoinTestnet, PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4, Bech32HRP: "tb"},
+	BitcoinRegtest: {Name: BitcoinRegtest, PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0xc4, Bech32HRP: "bcrt"},
+}
+
+// GetBitcoinNetwork returns the parameters of the network, mainnet when name is empty.
+func GetBitcoinNetwork(name string) (*BitcoinNetwork, error) {
+	if name == "" {
+		name = BitcoinMainnet
+	}
+	if network, ok := bitcoinNetworks[name]; ok {
+		return network, nil
+	}
+	return nil, fmt.Errorf("unknown bitcoin network: %v", name)
+}
+
+// BitcoinChain derives the addresses of one network and address type.
+// The zero value derives mainnet P2WPKH addresses.
+type BitcoinChain struct {
+	PrivateKey  *secp256k1.PrivateKey
+	Network     string
+	AddressType string
+}
+
+func (c BitcoinChain) GetPrivateKeySerialized() []byte {
+	return c.PrivateKey.Serialize()
+}
+
+func (c BitcoinChain) GetPublicKeySerialized() []byte {
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c BitcoinChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeCompressed()
+}
+
+func (c BitcoinChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
+	pubKey, err := secp256k1.ParsePubKey(pubKeySerialized)
+	if err != nil {
+		return ""
+	}
+
+	addressType := c.AddressType
+	if addressType == "" {
+		addressType = BitcoinP2WPKH
+	}
+	address, err := BitcoinAddress(pubKey, c.Network, addressType)
+	if err != nil {
+		return ""
+	}
+	return address
+}
+
+func (c BitcoinChain) SignCompact(msgHash []byte) (string, error) {
+	signature := ecdsa.SignCompact(c.PrivateKey, msgHash, false)
+
+	compactSig := rearrangeSignature(signature, true)
+
+	base64Sign := b64.StdEncoding.EncodeToString(compactSig)
+	return base64Sign, nil
+}
+
+func (c BitcoinChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	return RecoverCompact(msgHash, signature)
+}
+
+// BitcoinAddress returns the address of the public key on the network.
+func BitcoinAddress(pubKey *secp256k1.PublicKey, networkName string, addressType string) (string, error) {
+	network, err := GetBitcoinNetwork(networkName)
+	if err != nil {
+		return "", err
+	}
+
+	switch addressType {
+	case BitcoinP2PKH:
+		return base58.CheckEncode(Hash160(pubKey.SerializeCompressed()), network.PubKeyHashAddrID), nil
+	case BitcoinP2WPKH:
+		return encodeSegWitAddress(network.Bech32HRP, 0, Hash160(pubKey.SerializeCompressed()))
+	case BitcoinP2TR:
+		outputKey, err := TaprootOutputKey(pubKey, nil)
+		if err != nil {
+			return "", err
+		}
+		return encodeSegWitAddress(network.Bech32HRP, 1, XOnlyPublicKey(outputKey))
+	}
+	return "", fmt.Errorf("unknown bitcoin address type: %v", addressType)
+}
+
+// BitcoinAddresses returns the address of the public key per address type.
+func BitcoinAddresses(pubKey *secp256k1.PublicKey, networkName string) (map[string]string, error) {
+	addresses := map[string]string{}
+	for _, addressType := range []string{BitcoinP2PKH, BitcoinP2WPKH, BitcoinP2TR} {
+		address, err := BitcoinAddress(pubKey, networkName, addressType)
+		if err != nil {
+			return nil, err
+		}
+		addresses[addressType] = address
+	}
+	return addresses, nil
+}
+
+// BitcoinScriptAddress returns the address of an output script on the network,
+// or the hex of the script when it has no address form.
+func BitcoinScriptAddress(script []byte, network *BitcoinNetwork) string {
+	var address string
+	var err error
+
+	switch {
+	case len(script) == 25 && script[0] == opDup && script[1] == opHash160 && script[2] == 20 &&
+		script[23] == opEqualVerify && script[24] == opCheckSig:
+		address = base58.CheckEncode(script[3:23], network.PubKeyHashAddrID)
+	case len(script) == 23 && script[0] == opHash160 && script[1] == 20 && script[22] == opEqual:
+		address = base58.CheckEncode(script[2:22], network.ScriptHashAddrID)
+	case len(script) == 22 && script[0] == op0 && script[1] == 20,
+		len(script) == 34 && script[0] == op0 && script[1] == 32:
+		address, err = encodeSegWitAddress(network.Bech32HRP, 0, script[2:])
+	case len(script) == 34 && script[0] == op1 && script[1] == 32:
+		address, err = encodeSegWitAddress(network.Bech32HRP, 1, script[2:])
+	}
+
+	if address == "" || err != nil {
+		return fmt.Sprintf("%x", script)
+	}
+	return address
+}
+
+// TaprootOutputKey returns the output key of the internal key tweaked with
+// the merkle root of its script tree, as in BIP-341.
+// BIP-86 key-path only outputs have no merkle root.
+func TaprootOutputKey(internalKey *secp256k1.PublicKey, merkleRoot []byte) (*secp256k1.PublicKey, error) {
+	xOnly := XOnlyPublicKey(internalKey)
+	tweak, err := taprootTweak(xOnly, merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	evenKey, err := ParseXOnlyPublicKey(xOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	// Q = P + t*G
+	var p, tG, q secp256k1.JacobianPoint
+	evenKey.AsJacobian(&p)
+	secp256k1.ScalarBaseMultNonConst(tweak, &tG)
+	secp256k1.AddNonConst(&p, &tG, &q)
+	if (q.X.IsZero() && q.Y.IsZero()) || q.Z.IsZero() {
+		return nil, fmt.Errorf("invalid taproot output key")
+	}
+	q.ToAffine()
+	return secp256k1.NewPublicKey(&q.X, &q.Y), nil
+}
+
+// TaprootTweakPrivateKey returns the private key of the taproot output key.
+func TaprootTweakPrivateKey(privateKey *secp256k1.PrivateKey, merkleRoot []byte) (*secp256k1.PrivateKey, error) {
+	pubKey := privateKey.PubKey()
+	tweak, err := taprootTweak(XOnlyPublicKey(pubKey), merkleRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var d secp256k1.ModNScalar
+	d.Set(&privateKey.Key)
+	if pubKey.SerializeCompressed()[0] == secp256k1.PubKeyFormatCompressedOdd {
+		d.Negate()
+	}
+	d.Add(tweak)
+	if d.IsZero() {
+		return nil, fmt.Errorf("invalid taproot tweaked key")
+	}
+	return secp256k1.NewPrivateKey(&d), nil
+}
+
+func taprootTweak(xOnly []byte, merkleRoot []byte) (*secp256k1.ModNScalar, error) {
+	if merkleRoot != nil && len(merkleRoot) != 32 {
+		return nil, fmt.Errorf("invalid taproot merkle root length: %v", len(merkleRoot))
+	}
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(TaggedHash("TapTweak", xOnly, merkleRoot)); overflow {
+		return nil, fmt.Errorf("invalid taproot tweak")
+	}
+	return &tweak, nil
+}
+
+// Hash160 returns RIPEMD160(SHA256(b)).
+func Hash160(b []byte) []byte {
+	sha := sha256Sum(b)
+	hasher := ripemd160.New()
+	hasher.Write(sha)
+	return hasher.Sum(nil)
+}
+
+// encodeSegWitAddress encodes a witness program with bech32 for version 0
+// and with bech32m for the later versions, as in BIP-173 and BIP-350.
+func encodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data := append([]byte{version}, converted...)
+
+	checksumConst := uint32(bech32Const)
+	if version > 0 {
+		checksumConst = bech32mConst
+	}
+
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ checksumConst
+
+	var address strings.Builder
+	address.WriteString(hrp)
+	address.WriteByte('1')
+	for _, b := range data {
+		address.WriteByte(bech32Charset[b])
+	}
+	for i := 0; i < 6; i++ {
+		address.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return address.String(), nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32HRPExpand(hrp string) []byte {
+	var expanded bytes.Buffer
+	for i := 0; i < len(hrp); i++ {
+		expanded.WriteByte(hrp[i] >> 5)
+	}
+	expanded.WriteByte(0)
+	for i := 0; i < len(hrp); i++ {
+		expanded.WriteByte(hrp[i] & 31)
+	}
+	return expanded.Bytes()
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
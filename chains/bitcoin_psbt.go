@@ -0,0 +1,374 @@
+package chains
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// PSBT key types of BIP-174 and BIP-371 used for signing.
+const (
+	psbtGlobalUnsignedTx = 0x00
+
+	psbtInNonWitnessUtxo = 0x00
+	psbtInWitnessUtxo    = 0x01
+	psbtInPartialSig     = 0x02
+	psbtInSighashType    = 0x03
+	psbtInRedeemScript   = 0x04
+	psbtInTapKeySig      = 0x13
+	psbtInTapInternalKey = 0x17
+	psbtInTapMerkleRoot  = 0x18
+
+	psbtSeparator     = 0x00
+	psbtMagic         = "psbt\xff"
+	maxPsbtMapEntries = 10000
+)
+
+type psbtPair struct {
+	Key   []byte
+	Value []byte
+}
+
+// psbtMap keeps the key-value pairs of a PSBT map in their original order,
+// so that the fields unknown to the signer are passed through.
+type psbtMap []psbtPair
+
+func (m psbtMap) get(keyType byte) []byte {
+	for _, pair := range m {
+		if len(pair.Key) == 1 && pair.Key[0] == keyType {
+			return pair.Value
+		}
+	}
+	return nil
+}
+
+func (m *psbtMap) set(key []byte, value []byte) {
+	for i, pair := range *m {
+		if bytes.Equal(pair.Key, key) {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, psbtPair{Key: key, Value: value})
+}
+
+// Psbt is a partially signed bitcoin transaction of BIP-174 version 0.
+type Psbt struct {
+	UnsignedTx *BitcoinTx
+	global     psbtMap
+	inputs     []psbtMap
+	outputs    []psbtMap
+}
+
+// ParsePsbtBase64 parses a base64 encoded PSBT.
+func ParsePsbtBase64(s string) (*Psbt, error) {
+	b, err := b64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid psbt encoding: %w", err)
+	}
+	return ParsePsbt(b)
+}
+
+// ParsePsbt parses a serialized PSBT.
+func ParsePsbt(b []byte) (*Psbt, error) {
+	if !bytes.HasPrefix(b, []byte(psbtMagic)) {
+		return nil, fmt.Errorf("invalid psbt magic")
+	}
+	r := bytes.NewReader(b[len(psbtMagic):])
+
+	global, err := readPsbtMap(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Psbt)
+	for _, pair := range global {
+		if len(pair.Key) == 1 && pair.Key[0] == psbtGlobalUnsignedTx {
+			if p.UnsignedTx, err = ParseBitcoinTx(pair.Value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.global = append(p.global, pair)
+	}
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("invalid psbt: missing unsigned transaction")
+	}
+	for _, in := range p.UnsignedTx.Inputs {
+		if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+			return nil, fmt.Errorf("invalid psbt: unsigned transaction has signatures")
+		}
+	}
+
+	for range p.UnsignedTx.Inputs {
+		input, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+		p.inputs = append(p.inputs, input)
+	}
+	for range p.UnsignedTx.Outputs {
+		output, err := readPsbtMap(r)
+		if err != nil {
+			return nil, err
+		}
+		p.outputs = append(p.outputs, output)
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid psbt: %v trailing bytes", r.Len())
+	}
+	return p, nil
+}
+
+func readPsbtMap(r *bytes.Reader) (psbtMap, error) {
+	var m psbtMap
+	seen := map[string]bool{}
+	for {
+		key, err := readVarBytes(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid psbt: %w", err)
+		}
+		if len(key) == 0 {
+			return m, nil
+		}
+
+		value, err := readVarBytes(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid psbt: %w", err)
+		}
+		if seen[string(key)] {
+			return nil, fmt.Errorf("invalid psbt: duplicate key %x", key)
+		}
+		if len(m) >= maxPsbtMapEntries {
+			return nil, fmt.Errorf("invalid psbt: too many entries")
+		}
+		seen[string(key)] = true
+		m = append(m, psbtPair{Key: key, Value: value})
+	}
+}
+
+// Serialize returns the serialized PSBT.
+func (p *Psbt) Serialize() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(psbtMagic)
+
+	writeVarBytes(&buf, []byte{psbtGlobalUnsignedTx})
+	writeVarBytes(&buf, p.UnsignedTx.SerializeNoWitness())
+	writePsbtMap(&buf, p.global)
+
+	for _, input := range p.inputs {
+		writePsbtMap(&buf, input)
+	}
+	for _, output := range p.outputs {
+		writePsbtMap(&buf, output)
+	}
+	return buf.Bytes()
+}
+
+// Base64 returns the base64 encoded PSBT.
+func (p *Psbt) Base64() string {
+	return b64.StdEncoding.EncodeToString(p.Serialize())
+}
+
+func writePsbtMap(w *bytes.Buffer, m psbtMap) {
+	for _, pair := range m {
+		writeVarBytes(w, pair.Key)
+		writeVarBytes(w, pair.Value)
+	}
+	w.WriteByte(psbtSeparator)
+}
+
+// PrevOut returns the output spent by the input, from its witness or non-witness UTXO.
+func (p *Psbt) PrevOut(index int) (*BitcoinTxOut, error) {
+	input := p.inputs[index]
+	txIn := p.UnsignedTx.Inputs[index]
+
+	if value := input.get(psbtInWitnessUtxo); value != nil {
+		r := bytes.NewReader(value)
+		out := new(BitcoinTxOut)
+		if err := binary.Read(r, binary.LittleEndian, &out.Value); err != nil {
+			return nil, fmt.Errorf("invalid witness utxo of input %v: %w", index, err)
+		}
+		script, err := readVarBytes(r)
+		if err != nil || r.Len() != 0 {
+			return nil, fmt.Errorf("invalid witness utxo of input %v", index)
+		}
+		out.Script = script
+		return out, nil
+	}
+
+	if value := input.get(psbtInNonWitnessUtxo); value != nil {
+		prevTx, err := p.nonWitnessUtxo(index)
+		if err != nil {
+			return nil, err
+		}
+		return prevTx.Outputs[txIn.PrevIndex], nil
+	}
+
+	return nil, fmt.Errorf("missing utxo of input %v", index)
+}
+
+// nonWitnessUtxo returns the full previous transaction of the input,
+// checked against the outpoint it spends.
+func (p *Psbt) nonWitnessUtxo(index int) (*BitcoinTx, error) {
+	value := p.inputs[index].get(psbtInNonWitnessUtxo)
+	if value == nil {
+		return nil, fmt.Errorf("missing non-witness utxo of input %v", index)
+	}
+
+	prevTx, err := ParseBitcoinTx(value)
+	if err != nil {
+		return nil, err
+	}
+
+	txIn := p.UnsignedTx.Inputs[index]
+	if prevTx.TxID() != txIn.PrevHash {
+		return nil, fmt.Errorf("non-witness utxo of input %v does not match its outpoint", index)
+	}
+	if int(txIn.PrevIndex) >= len(prevTx.Outputs) {
+		return nil, fmt.Errorf("invalid outpoint index of input %v", index)
+	}
+	return prevTx, nil
+}
+
+func (p *Psbt) sigHashType(index int, defaultType uint32) (uint32, error) {
+	value := p.inputs[index].get(psbtInSighashType)
+	if value == nil {
+		return defaultType, nil
+	}
+	if len(value) != 4 {
+		return 0, fmt.Errorf("invalid sighash type of input %v", index)
+	}
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// PsbtSignature is a signature added to an input of a PSBT.
+type PsbtSignature struct {
+	Index     int
+	SigHash   []byte
+	Signature []byte
+}
+
+// SignPsbt signs every input of the PSBT spending an output of the private key:
+// P2PKH, P2WPKH and P2SH-P2WPKH inputs with ECDSA, and P2TR key path inputs with
+// BIP-340 Schnorr. The signatures are added as partial signatures, the inputs
+// are left for a finalizer.
+func SignPsbt(p *Psbt, privateKey *secp256k1.PrivateKey) ([]PsbtSignature, error) {
+	pubKey := privateKey.PubKey().SerializeCompressed()
+	xOnly := pubKey[1:]
+
+	p2pkh := P2PKHScript(pubKey)
+	p2wpkh := P2WPKHScript(pubKey)
+	p2shP2wpkh := P2SHScript(p2wpkh)
+
+	var signatures []PsbtSignature
+	for i, input := range p.inputs {
+		prevOut, err := p.PrevOut(i)
+		if err != nil {
+			// An input without its UTXO can not be ours to sign.
+			continue
+		}
+
+		var sigHash []byte
+		var signature []byte
+		switch {
+		case bytes.Equal(prevOut.Script, p2pkh), bytes.Equal(prevOut.Script, p2wpkh), bytes.Equal(prevOut.Script, p2shP2wpkh):
+			hashType, err := p.sigHashType(i, SigHashAll)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case bytes.Equal(prevOut.Script, p2pkh):
+				// Legacy inputs commit to their amount through the full previous transaction.
+				if _, err := p.nonWitnessUtxo(i); err != nil {
+					return nil, err
+				}
+				sigHash, err = p.UnsignedTx.LegacySigHash(i, p2pkh, hashType)
+			default:
+				if bytes.Equal(prevOut.Script, p2shP2wpkh) && input.get(psbtInRedeemScript) == nil {
+					input.set([]byte{psbtInRedeemScript}, p2wpkh)
+				}
+				sigHash, err = p.UnsignedTx.WitnessV0SigHash(i, p2pkh, prevOut.Value, hashType)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error signing input %v: %w", i, err)
+			}
+
+			signature = append(ecdsa.Sign(privateKey, sigHash).Serialize(), byte(hashType))
+			input.set(append([]byte{psbtInPartialSig}, pubKey...), signature)
+
+		case len(prevOut.Script) == 34 && prevOut.Script[0] == op1 && prevOut.Script[1] == 32:
+			if internalKey := input.get(psbtInTapInternalKey); internalKey != nil && !bytes.Equal(internalKey, xOnly) {
+				continue
+			}
+			merkleRoot := input.get(psbtInTapMerkleRoot)
+			outputKey, err := TaprootOutputKey(privateKey.PubKey(), merkleRoot)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(prevOut.Script, P2TRScript(XOnlyPublicKey(outputKey))) {
+				continue
+			}
+
+			hashType, err := p.sigHashType(i, SigHashDefault)
+			if err != nil {
+				return nil, err
+			}
+
+			prevOuts := make([]*BitcoinTxOut, len(p.inputs))
+			for j := range p.inputs {
+				if prevOuts[j], err = p.PrevOut(j); err != nil {
+					return nil, fmt.Errorf("error signing input %v: %w", i, err)
+				}
+			}
+			if sigHash, err = p.UnsignedTx.TaprootSigHash(i, prevOuts, hashType); err != nil {
+				return nil, fmt.Errorf("error signing input %v: %w", i, err)
+			}
+
+			tweakedKey, err := TaprootTweakPrivateKey(privateKey, merkleRoot)
+			if err != nil {
+				return nil, err
+			}
+			if signature, err = SchnorrSign(tweakedKey, sigHash); err != nil {
+				return nil, err
+			}
+			tweakedKey.Zero()
+
+			if hashType != SigHashDefault {
+				signature = append(signature, byte(hashType))
+			}
+			input.set([]byte{psbtInTapKeySig}, signature)
+			if input.get(psbtInTapInternalKey) == nil {
+				input.set([]byte{psbtInTapInternalKey}, xOnly)
+			}
+
+		default:
+			continue
+		}
+
+		p.inputs[i] = input
+		signatures = append(signatures, PsbtSignature{Index: i, SigHash: sigHash, Signature: signature})
+	}
+
+	return signatures, nil
+}
+
+// BitcoinOwnedScript reports whether an output script pays to the public key,
+// with any of the address types of BitcoinAddresses.
+func BitcoinOwnedScript(script []byte, pubKey *secp256k1.PublicKey) bool {
+	serialized := pubKey.SerializeCompressed()
+	if bytes.Equal(script, P2PKHScript(serialized)) ||
+		bytes.Equal(script, P2WPKHScript(serialized)) ||
+		bytes.Equal(script, P2SHScript(P2WPKHScript(serialized))) {
+		return true
+	}
+
+	outputKey, err := TaprootOutputKey(pubKey, nil)
+	return err == nil && bytes.Equal(script, P2TRScript(XOnlyPublicKey(outputKey)))
+}
@@ -0,0 +1,334 @@
+package chains
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoinAddress(t *testing.T) {
+	// The first receive addresses of the "abandon ... about" mnemonic in BIP-44, BIP-84 and BIP-86.
+	tests := []struct {
+		pubKey      string
+		addressType string
+		network     string
+		expected    string
+	}{
+		{"03aaeb52dd7494c361049de67cc680e83ebcbbbdbeb13637d92cd845f70308af5e", BitcoinP2PKH, BitcoinMainnet, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"0330d54fd0dd420a6e5f8d3624f5f3482cae350f79d5f0753bf5beef9c2d91af3c", BitcoinP2WPKH, BitcoinMainnet, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{"02cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115", BitcoinP2TR, BitcoinMainnet, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	}
+
+	for _, test := range tests {
+		pubKeyBytes, _ := hex.DecodeString(test.pubKey)
+		pubKey, err := secp256k1.ParsePubKey(pubKeyBytes)
+		require.NoError(t, err)
+
+		address, err := BitcoinAddress(pubKey, test.network, test.addressType)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, address)
+
+		chain := BitcoinChain{Network: test.network, AddressType: test.addressType}
+		require.Equal(t, test.expected, chain.GetPublicKeyAddress(chain.SerializePublicKey(pubKey)))
+	}
+
+	pubKeyBytes, _ := hex.DecodeString(tests[1].pubKey)
+	pubKey, _ := secp256k1.ParsePubKey(pubKeyBytes)
+
+	address, err := BitcoinAddress(pubKey, BitcoinTestnet, BitcoinP2WPKH)
+	require.NoError(t, err)
+	require.Equal(t, "tb1qcr8te4kr609gcawutmrza0j4xv80jy8zmfp6l0", address)
+
+	address, err = BitcoinAddress(pubKey, BitcoinRegtest, BitcoinP2PKH)
+	require.NoError(t, err)
+	require.Equal(t, byte('m'), address[0])
+
+	_, err = BitcoinAddress(pubKey, "signet", BitcoinP2WPKH)
+	require.ErrorContains(t, err, "unknown bitcoin network")
+}
+
+func TestSchnorrSign(t *testing.T) {
+	// Test vectors 0 to 14 of BIP-340, the ones without a private key only verify.
+	tests := []struct {
+		privateKey string
+		publicKey  string
+		auxRand    string
+		msg        string
+		signature  string
+		valid      bool
+	}{
+		{
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+			true,
+		},
+		{
+			"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+			true,
+		},
+		{
+			"C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+			"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+			"C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+			"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+			"5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+			true,
+		},
+		{
+			"0B432B2677937381AEF05BB02A66ECD012773062CF3FA2549E44F58ED2401710",
+			"25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+			"7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3",
+			true,
+		},
+		{
+			"",
+			"D69C3509BB99E412E68B0FE8544E72837DFA30746D8BE2AA65975F29D22DC7B9",
+			"",
+			"4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703",
+			"00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C6376AFB1548AF603B3EB45C9F8207DEE1060CB71C04E80F593060B07D28308D7F4",
+			true,
+		},
+		{
+			"",
+			"EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"FFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A14602975563CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769961764B3AA9B2FFCB6EF947B6887A226E8D7C93E00C5ED0C1834FF0D0C2E6DA6",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"0000000000000000000000000000000000000000000000000000000000000000123DDA8328AF9C23A94C1FEECFD123BA4FB73476F0D594DCB65C6425BD186051",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"00000000000000000000000000000000000000000000000000000000000000017615FBAF5AE28864013C099742DEADB4DBA87F11AC6754F93780D5A1837CF197",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"4A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			false,
+		},
+		{
+			"",
+			"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141",
+			false,
+		},
+		{
+			"",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+			"",
+			"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			"6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B",
+			false,
+		},
+	}
+
+	for i, test := range tests {
+		publicKey, _ := hex.DecodeString(test.publicKey)
+		msg, _ := hex.DecodeString(test.msg)
+		expected, _ := hex.DecodeString(test.signature)
+		require.Equal(t, test.valid, SchnorrVerify(publicKey, msg, expected), "vector %v", i)
+
+		if test.privateKey == "" {
+			continue
+		}
+		privateKeyBytes, _ := hex.DecodeString(test.privateKey)
+		auxRand, _ := hex.DecodeString(test.auxRand)
+
+		privateKey := secp256k1.PrivKeyFromBytes(privateKeyBytes)
+		require.Equal(t, publicKey, XOnlyPublicKey(privateKey.PubKey()))
+
+		signature, err := schnorrSign(&privateKey.Key, msg, auxRand)
+		require.NoError(t, err)
+		require.Equal(t, expected, signature, "vector %v", i)
+
+		msg[0] ^= 0x01
+		require.False(t, SchnorrVerify(publicKey, msg, signature))
+	}
+}
+
+func TestTaprootOutputKey(t *testing.T) {
+	// The scriptPubKey test vectors of BIP-341.
+	tests := []struct {
+		internalKey string
+		merkleRoot  string
+		outputKey   string
+	}{
+		{
+			"d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d",
+			"",
+			"53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343",
+		},
+		{
+			"187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27",
+			"5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21",
+			"147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3",
+		},
+	}
+
+	for _, test := range tests {
+		xOnly, _ := hex.DecodeString(test.internalKey)
+		internalKey, err := ParseXOnlyPublicKey(xOnly)
+		require.NoError(t, err)
+
+		var merkleRoot []byte
+		if test.merkleRoot != "" {
+			merkleRoot, _ = hex.DecodeString(test.merkleRoot)
+		}
+		outputKey, err := TaprootOutputKey(internalKey, merkleRoot)
+		require.NoError(t, err)
+		require.Equal(t, test.outputKey, hex.EncodeToString(XOnlyPublicKey(outputKey)))
+	}
+
+	// The key path spending test vector of BIP-341.
+	privateKeyBytes, _ := hex.DecodeString("6b973d88838f27366ed61c9ad6367663045cb456e28335c109e30717ae0c6baa")
+	privateKey := secp256k1.PrivKeyFromBytes(privateKeyBytes)
+	require.Equal(t, "d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d", hex.EncodeToString(XOnlyPublicKey(privateKey.PubKey())))
+
+	tweakedKey, err := TaprootTweakPrivateKey(privateKey, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2405b971772ad26915c8dcdf10f238753a9b837e5f8e6a86fd7c0cce5b7296d9", hex.EncodeToString(tweakedKey.Serialize()))
+
+	_, err = TaprootOutputKey(privateKey.PubKey(), []byte{0x01})
+	require.ErrorContains(t, err, "invalid taproot merkle root length")
+}
+
+func TestWitnessV0SigHash(t *testing.T) {
+	// The native P2WPKH example of BIP-143.
+	unsignedTx, _ := hex.DecodeString("0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000")
+	scriptCode, _ := hex.DecodeString("76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+
+	tx, err := ParseBitcoinTx(unsignedTx)
+	require.NoError(t, err)
+	require.Equal(t, unsignedTx, tx.Serialize())
+
+	sigHash, err := tx.WitnessV0SigHash(1, scriptCode, 600000000, SigHashAll)
+	require.NoError(t, err)
+	require.Equal(t, "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670", hex.EncodeToString(sigHash))
+}
+
+func TestSignPsbt(t *testing.T) {
+	privateKey, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+	pubKey := privateKey.PubKey()
+
+	outputKey, err := TaprootOutputKey(pubKey, nil)
+	require.NoError(t, err)
+
+	otherKey, err := secp256k1.GeneratePrivateKey()
+	require.NoError(t, err)
+
+	prevOuts := []*BitcoinTxOut{
+		{Value: 50000, Script: P2WPKHScript(pubKey.SerializeCompressed())},
+		{Value: 70000, Script: P2TRScript(XOnlyPublicKey(outputKey))},
+		{Value: 90000, Script: P2WPKHScript(otherKey.PubKey().SerializeCompressed())},
+	}
+
+	tx := &BitcoinTx{Version: 2}
+	for i := range prevOuts {
+		tx.Inputs = append(tx.Inputs, &BitcoinTxIn{PrevHash: [32]byte{byte(i + 1)}, Sequence: 0xfffffffd})
+	}
+	tx.Outputs = []*BitcoinTxOut{{Value: 200000, Script: P2WPKHScript(otherKey.PubKey().SerializeCompressed())}}
+
+	packet := &Psbt{UnsignedTx: tx, outputs: []psbtMap{nil}}
+	packet.global = psbtMap{{Key: []byte{0xfc, 0x01}, Value: []byte("proprietary")}}
+	for _, prevOut := range prevOuts {
+		var witnessUtxo []byte
+		witnessUtxo = binary.LittleEndian.AppendUint64(witnessUtxo, uint64(prevOut.Value))
+		witnessUtxo = append(witnessUtxo, byte(len(prevOut.Script)))
+		witnessUtxo = append(witnessUtxo, prevOut.Script...)
+		packet.inputs = append(packet.inputs, psbtMap{{Key: []byte{psbtInWitnessUtxo}, Value: witnessUtxo}})
+	}
+
+	parsed, err := ParsePsbtBase64(packet.Base64())
+	require.NoError(t, err)
+	require.Equal(t, packet.Serialize(), parsed.Serialize())
+
+	signatures, err := SignPsbt(parsed, privateKey)
+	require.NoError(t, err)
+	require.Len(t, signatures, 2)
+	require.Equal(t, 0, signatures[0].Index)
+	require.Equal(t, 1, signatures[1].Index)
+
+	signed, err := ParsePsbtBase64(parsed.Base64())
+	require.NoError(t, err)
+	require.Equal(t, []byte("proprietary"), signed.global[0].Value)
+
+	partialSig := signed.inputs[0][1]
+	require.Equal(t, append([]byte{psbtInPartialSig}, pubKey.SerializeCompressed()...), partialSig.Key)
+	require.Equal(t, byte(SigHashAll), partialSig.Value[len(partialSig.Value)-1])
+	sig, err := ecdsa.ParseDERSignature(partialSig.Value[:len(partialSig.Value)-1])
+	require.NoError(t, err)
+
+	sigHash, err := tx.WitnessV0SigHash(0, P2PKHScript(pubKey.SerializeCompressed()), 50000, SigHashAll)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(sigHash, pubKey))
+
+	tapKeySig := signed.inputs[1].get(psbtInTapKeySig)
+	require.Len(t, tapKeySig, SchnorrSignatureLength)
+	sigHash, err = tx.TaprootSigHash(1, prevOuts, SigHashDefault)
+	require.NoError(t, err)
+	require.True(t, SchnorrVerify(XOnlyPublicKey(outputKey), sigHash, tapKeySig))
+
+	require.Nil(t, signed.inputs[2].get(psbtInPartialSig))
+}
@@ -0,0 +1,411 @@
+package chains
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	op0           = 0x00
+	op1           = 0x51
+	opDup         = 0x76
+	opEqual       = 0x87
+	opEqualVerify = 0x88
+	opHash160     = 0xa9
+	opCheckSig    = 0xac
+
+	SigHashDefault = 0x00
+	SigHashAll     = 0x01
+
+	// maxBitcoinTxItems bounds the counts read from untrusted transactions.
+	maxBitcoinTxItems = 100000
+)
+
+type BitcoinTxIn struct {
+	PrevHash  [32]byte
+	PrevIndex uint32
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+}
+
+type BitcoinTxOut struct {
+	Value  int64
+	Script []byte
+}
+
+// BitcoinTx is a bitcoin transaction, as serialized in BIP-144.
+type BitcoinTx struct {
+	Version  int32
+	Inputs   []*BitcoinTxIn
+	Outputs  []*BitcoinTxOut
+	LockTime uint32
+}
+
+// ParseBitcoinTx parses a serialized transaction, with or without witness data.
+func ParseBitcoinTx(b []byte) (*BitcoinTx, error) {
+	r := bytes.NewReader(b)
+	tx, err := readBitcoinTx(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bitcoin transaction: %w", err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("invalid bitcoin transaction: %v trailing bytes", r.Len())
+	}
+	return tx, nil
+}
+
+func readBitcoinTx(r *bytes.Reader) (*BitcoinTx, error) {
+	tx := new(BitcoinTx)
+	if err := binary.Read(r, binary.LittleEndian, &tx.Version); err != nil {
+		return nil, err
+	}
+
+	inputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty input list is the marker of the witness serialization.
+	hasWitness := false
+	if inputCount == 0 {
+		flag, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if flag != 0x01 {
+			return nil, fmt.Errorf("invalid witness flag: %v", flag)
+		}
+		hasWitness = true
+		if inputCount, err = readVarInt(r); err != nil {
+			return nil, err
+		}
+	}
+	if inputCount > maxBitcoinTxItems {
+		return nil, fmt.Errorf("too many inputs: %v", inputCount)
+	}
+
+	for i := uint64(0); i < inputCount; i++ {
+		in := new(BitcoinTxIn)
+		if _, err := io.ReadFull(r, in.PrevHash[:]); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &in.PrevIndex); err != nil {
+			return nil, err
+		}
+		if in.ScriptSig, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &in.Sequence); err != nil {
+			return nil, err
+		}
+		tx.Inputs = append(tx.Inputs, in)
+	}
+
+	outputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if outputCount > maxBitcoinTxItems {
+		return nil, fmt.Errorf("too many outputs: %v", outputCount)
+	}
+
+	for i := uint64(0); i < outputCount; i++ {
+		out := new(BitcoinTxOut)
+		if err := binary.Read(r, binary.LittleEndian, &out.Value); err != nil {
+			return nil, err
+		}
+		if out.Script, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+		tx.Outputs = append(tx.Outputs, out)
+	}
+
+	if hasWitness {
+		for _, in := range tx.Inputs {
+			itemCount, err := readVarInt(r)
+			if err != nil {
+				return nil, err
+			}
+			if itemCount > maxBitcoinTxItems {
+				return nil, fmt.Errorf("too many witness items: %v", itemCount)
+			}
+			for j := uint64(0); j < itemCount; j++ {
+				item, err := readVarBytes(r)
+				if err != nil {
+					return nil, err
+				}
+				in.Witness = append(in.Witness, item)
+			}
+		}
+	}
+
+	if err := binary.Read(r, binary.LittleEndian, &tx.LockTime); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Serialize returns the transaction with its witness data, if any input has some.
+func (tx *BitcoinTx) Serialize() []byte {
+	hasWitness := false
+	for _, in := range tx.Inputs {
+		if len(in.Witness) > 0 {
+			hasWitness = true
+		}
+	}
+	return tx.serialize(hasWitness)
+}
+
+// SerializeNoWitness returns the transaction without witness data, as its txid commits to.
+func (tx *BitcoinTx) SerializeNoWitness() []byte {
+	return tx.serialize(false)
+}
+
+// TxID returns the transaction hash in its internal byte order.
+func (tx *BitcoinTx) TxID() [32]byte {
+	return doubleSha256(tx.SerializeNoWitness())
+}
+
+func (tx *BitcoinTx) serialize(witness bool) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, tx.Version)
+	if witness {
+		buf.Write([]byte{0x00, 0x01})
+	}
+
+	writeVarInt(&buf, uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		writeOutPoint(&buf, in)
+		writeVarBytes(&buf, in.ScriptSig)
+		binary.Write(&buf, binary.LittleEndian, in.Sequence)
+	}
+
+	writeVarInt(&buf, uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		writeTxOut(&buf, out)
+	}
+
+	if witness {
+		for _, in := range tx.Inputs {
+			writeVarInt(&buf, uint64(len(in.Witness)))
+			for _, item := range in.Witness {
+				writeVarBytes(&buf, item)
+			}
+		}
+	}
+
+	binary.Write(&buf, binary.LittleEndian, tx.LockTime)
+	return buf.Bytes()
+}
+
+// LegacySigHash returns the pre-SegWit signature hash of the input spending prevScript.
+func (tx *BitcoinTx) LegacySigHash(index int, prevScript []byte, hashType uint32) ([]byte, error) {
+	if hashType != SigHashAll {
+		return nil, fmt.Errorf("unsupported sighash type: %v", hashType)
+	}
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("invalid input index: %v", index)
+	}
+
+	copied := &BitcoinTx{Version: tx.Version, Outputs: tx.Outputs, LockTime: tx.LockTime}
+	for i, in := range tx.Inputs {
+		in := *in
+		in.ScriptSig, in.Witness = nil, nil
+		if i == index {
+			in.ScriptSig = prevScript
+		}
+		copied.Inputs = append(copied.Inputs, &in)
+	}
+
+	preimage := copied.SerializeNoWitness()
+	preimage = binary.LittleEndian.AppendUint32(preimage, hashType)
+
+	hash := doubleSha256(preimage)
+	return hash[:], nil
+}
+
+// WitnessV0SigHash returns the BIP-143 signature hash of a SegWit v0 input.
+func (tx *BitcoinTx) WitnessV0SigHash(index int, scriptCode []byte, amount int64, hashType uint32) ([]byte, error) {
+	if hashType != SigHashAll {
+		return nil, fmt.Errorf("unsupported sighash type: %v", hashType)
+	}
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("invalid input index: %v", index)
+	}
+
+	var prevouts, sequences, outputs bytes.Buffer
+	for _, in := range tx.Inputs {
+		writeOutPoint(&prevouts, in)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		writeTxOut(&outputs, out)
+	}
+	hashPrevouts := doubleSha256(prevouts.Bytes())
+	hashSequence := doubleSha256(sequences.Bytes())
+	hashOutputs := doubleSha256(outputs.Bytes())
+
+	in := tx.Inputs[index]
+	var preimage bytes.Buffer
+	binary.Write(&preimage, binary.LittleEndian, tx.Version)
+	preimage.Write(hashPrevouts[:])
+	preimage.Write(hashSequence[:])
+	writeOutPoint(&preimage, in)
+	writeVarBytes(&preimage, scriptCode)
+	binary.Write(&preimage, binary.LittleEndian, amount)
+	binary.Write(&preimage, binary.LittleEndian, in.Sequence)
+	preimage.Write(hashOutputs[:])
+	binary.Write(&preimage, binary.LittleEndian, tx.LockTime)
+	binary.Write(&preimage, binary.LittleEndian, hashType)
+
+	hash := doubleSha256(preimage.Bytes())
+	return hash[:], nil
+}
+
+// TaprootSigHash returns the BIP-341 signature hash of a key path spend.
+// prevOuts are the outputs spent by every input of the transaction.
+func (tx *BitcoinTx) TaprootSigHash(index int, prevOuts []*BitcoinTxOut, hashType uint32) ([]byte, error) {
+	if hashType != SigHashDefault && hashType != SigHashAll {
+		return nil, fmt.Errorf("unsupported sighash type: %v", hashType)
+	}
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("invalid input index: %v", index)
+	}
+	if len(prevOuts) != len(tx.Inputs) {
+		return nil, fmt.Errorf("taproot signing requires the spent outputs of all inputs")
+	}
+
+	var prevouts, amounts, scripts, sequences, outputs bytes.Buffer
+	for i, in := range tx.Inputs {
+		writeOutPoint(&prevouts, in)
+		binary.Write(&amounts, binary.LittleEndian, prevOuts[i].Value)
+		writeVarBytes(&scripts, prevOuts[i].Script)
+		binary.Write(&sequences, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		writeTxOut(&outputs, out)
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(byte(hashType))
+	binary.Write(&msg, binary.LittleEndian, tx.Version)
+	binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+	msg.Write(sha256Sum(prevouts.Bytes()))
+	msg.Write(sha256Sum(amounts.Bytes()))
+	msg.Write(sha256Sum(scripts.Bytes()))
+	msg.Write(sha256Sum(sequences.Bytes()))
+	msg.Write(sha256Sum(outputs.Bytes()))
+	msg.WriteByte(0x00) // key path spend without annex
+	binary.Write(&msg, binary.LittleEndian, uint32(index))
+
+	return TaggedHash("TapSighash", msg.Bytes()), nil
+}
+
+// P2PKHScript returns the output script paying to the hash of the public key.
+func P2PKHScript(pubKey []byte) []byte {
+	script := []byte{opDup, opHash160, 20}
+	script = append(script, Hash160(pubKey)...)
+	return append(script, opEqualVerify, opCheckSig)
+}
+
+// P2WPKHScript returns the SegWit v0 output script paying to the hash of the public key.
+func P2WPKHScript(pubKey []byte) []byte {
+	return append([]byte{op0, 20}, Hash160(pubKey)...)
+}
+
+// P2SHScript returns the output script paying to the hash of the redeem script.
+func P2SHScript(redeemScript []byte) []byte {
+	script := []byte{opHash160, 20}
+	script = append(script, Hash160(redeemScript)...)
+	return append(script, opEqual)
+}
+
+// P2TRScript returns the SegWit v1 output script paying to the x-only output key.
+func P2TRScript(outputKey []byte) []byte {
+	return append([]byte{op1, 32}, outputKey...)
+}
+
+func writeOutPoint(w *bytes.Buffer, in *BitcoinTxIn) {
+	w.Write(in.PrevHash[:])
+	binary.Write(w, binary.LittleEndian, in.PrevIndex)
+}
+
+func writeTxOut(w *bytes.Buffer, out *BitcoinTxOut) {
+	binary.Write(w, binary.LittleEndian, out.Value)
+	writeVarBytes(w, out.Script)
+}
+
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch prefix {
+	case 0xfd:
+		var v uint16
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xfe:
+		var v uint32
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return uint64(v), err
+	case 0xff:
+		var v uint64
+		err = binary.Read(r, binary.LittleEndian, &v)
+		return v, err
+	}
+	return uint64(prefix), nil
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		w.WriteByte(0xfd)
+		binary.Write(w, binary.LittleEndian, uint16(v))
+	case v <= 0xffffffff:
+		w.WriteByte(0xfe)
+		binary.Write(w, binary.LittleEndian, uint32(v))
+	default:
+		w.WriteByte(0xff)
+		binary.Write(w, binary.LittleEndian, v)
+	}
+}
+
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+func sha256Sum(b []byte) []byte {
+	hash := sha256.Sum256(b)
+	return hash[:]
+}
+
+func doubleSha256(b []byte) [32]byte {
+	first := sha256.Sum256(b)
+	return sha256.Sum256(first[:])
+}
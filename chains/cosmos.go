@@ -0,0 +1,298 @@
+package chains
+
+import (
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const (
+	// CosmosDefaultHRP is the bech32 prefix of the Cosmos Hub accounts.
+	CosmosDefaultHRP = "cosmos"
+
+	CosmosSignModeDirect    = "SIGN_MODE_DIRECT"
+	CosmosSignModeAminoJSON = "SIGN_MODE_LEGACY_AMINO_JSON"
+
+	cosmosSignatureLength = 64
+	cosmosMsgSendTypeURL  = "/cosmos.bank.v1beta1.MsgSend"
+	cosmosMsgSendType     = "cosmos-sdk/MsgSend"
+)
+
+var cosmosHRPRegex = regexp.MustCompile(`^[a-z][a-z0-9]{0,82}$`)
+
+// CosmosChain derives the addresses of a Cosmos SDK chain with the bech32 prefix HRP.
+// The zero value derives Cosmos Hub addresses.
+type CosmosChain struct {
+	PrivateKey *secp256k1.PrivateKey
+	HRP        string
+}
+
+func (c CosmosChain) GetPrivateKeySerialized() []byte {
+	return c.PrivateKey.Serialize()
+}
+
+func (c CosmosChain) GetPublicKeySerialized() []byte {
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c CosmosChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeCompressed()
+}
+
+func (c CosmosChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
+	hrp := c.HRP
+	if hrp == "" {
+		hrp = CosmosDefaultHRP
+	}
+
+	address, err := encodeCosmosAddress(hrp, Hash160(pubKeySerialized))
+	if err != nil {
+		return ""
+	}
+	return address
+}
+
+// SignCompact returns the <32-byte R><32-byte S> signature Cosmos expects, with a low S.
+func (c CosmosChain) SignCompact(msgHash []byte) (string, error) {
+	signature := ecdsa.SignCompact(c.PrivateKey, msgHash, true)
+
+	base64Sign := b64.StdEncoding.EncodeToString(signature[1:])
+	return base64Sign, nil
+}
+
+func (c CosmosChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	if pubKey == nil {
+		return nil, fmt.Errorf("missing public key to verify cosmos signature")
+	}
+	if len(signature) != cosmosSignatureLength {
+		return nil, fmt.Errorf("invalid signature length: %v", len(signature))
+	}
+
+	var r, s secp256k1.ModNScalar
+	if overflow := r.SetByteSlice(signature[:32]); overflow {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	if overflow := s.SetByteSlice(signature[32:]); overflow || s.IsOverHalfOrder() {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	if !ecdsa.NewSignature(&r, &s).Verify(msgHash, pubKey) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+	return pubKey, nil
+}
+
+// CosmosAddress returns the address of the public key with the bech32 prefix hrp.
+func CosmosAddress(pubKey *secp256k1.PublicKey, hrp string) (string, error) {
+	return encodeCosmosAddress(hrp, Hash160(pubKey.SerializeCompressed()))
+}
+
+func encodeCosmosAddress(hrp string, hash []byte) (string, error) {
+	if !cosmosHRPRegex.MatchString(hrp) {
+		return "", fmt.Errorf("invalid cosmos address prefix: %v", hrp)
+	}
+
+	converted, err := bech32.ConvertBits(hash, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, converted)
+}
+
+// CosmosSignDoc is the document signed in SIGN_MODE_DIRECT.
+type CosmosSignDoc struct {
+	BodyBytes     []byte
+	AuthInfoBytes []byte
+	ChainID       string
+	AccountNumber uint64
+}
+
+// ParseCosmosSignDoc parses a protobuf encoded SignDoc.
+func ParseCosmosSignDoc(b []byte) (*CosmosSignDoc, error) {
+	fields, err := parseProtoFields(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cosmos sign doc: %w", err)
+	}
+
+	doc := new(CosmosSignDoc)
+	for _, field := range fields {
+		switch {
+		case field.Number == 1 && field.WireType == protoBytes:
+			doc.BodyBytes = field.Bytes
+		case field.Number == 2 && field.WireType == protoBytes:
+			doc.AuthInfoBytes = field.Bytes
+		case field.Number == 3 && field.WireType == protoBytes:
+			doc.ChainID = string(field.Bytes)
+		case field.Number == 4 && field.WireType == protoVarint:
+			doc.AccountNumber = field.Varint
+		default:
+			return nil, fmt.Errorf("invalid cosmos sign doc: unexpected field %v", field.Number)
+		}
+	}
+	if doc.ChainID == "" {
+		return nil, fmt.Errorf("invalid cosmos sign doc: missing chain id")
+	}
+	return doc, nil
+}
+
+// Marshal returns the protobuf encoding of the SignDoc.
+func (doc *CosmosSignDoc) Marshal() []byte {
+	var b []byte
+	b = appendProtoBytes(b, 1, doc.BodyBytes)
+	b = appendProtoBytes(b, 2, doc.AuthInfoBytes)
+	b = appendProtoBytes(b, 3, []byte(doc.ChainID))
+	return appendProtoVarint(b, 4, doc.AccountNumber)
+}
+
+// CosmosMsg is a message of a Cosmos transaction, reduced to the
+// fields signing policies are checked against. To and Amount are only
+// read from the bank MsgSend messages, and left empty for the others.
+type CosmosMsg struct {
+	Type   string
+	To     string
+	Amount *big.Int
+}
+
+// Messages returns the messages of the TxBody of the SignDoc.
+// The recipient and the amount are read from the bank MsgSend messages,
+// summing their coins.
+func (doc *CosmosSignDoc) Messages() ([]CosmosMsg, error) {
+	fields, err := parseProtoFields(doc.BodyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cosmos tx body: %w", err)
+	}
+
+	var msgs []CosmosMsg
+	for _, field := range fields {
+		if field.Number != 1 || field.WireType != protoBytes {
+			continue
+		}
+
+		// google.protobuf.Any { string type_url = 1; bytes value = 2; }
+		anyFields, err := parseProtoFields(field.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cosmos tx message: %w", err)
+		}
+		var msg CosmosMsg
+		var value []byte
+		for _, anyField := range anyFields {
+			switch anyField.Number {
+			case 1:
+				msg.Type = string(anyField.Bytes)
+			case 2:
+				value = anyField.Bytes
+			}
+		}
+
+		if msg.Type == cosmosMsgSendTypeURL {
+			if msg.To, msg.Amount, err = parseCosmosMsgSend(value); err != nil {
+				return nil, err
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// parseCosmosMsgSend reads the recipient and the sum of the coins of a MsgSend
+// { string from_address = 1; string to_address = 2; repeated Coin amount = 3; }.
+func parseCosmosMsgSend(b []byte) (string, *big.Int, error) {
+	fields, err := parseProtoFields(b)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid cosmos MsgSend: %w", err)
+	}
+
+	var to string
+	amount := new(big.Int)
+	for _, field := range fields {
+		switch field.Number {
+		case 2:
+			to = string(field.Bytes)
+		case 3:
+			// Coin { string denom = 1; string amount = 2; }
+			coinFields, err := parseProtoFields(field.Bytes)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid cosmos coin: %w", err)
+			}
+			for _, coinField := range coinFields {
+				if coinField.Number != 2 {
+					continue
+				}
+				coinAmount, ok := new(big.Int).SetString(string(coinField.Bytes), 10)
+				if !ok {
+					return "", nil, fmt.Errorf("invalid cosmos coin amount: %v", string(coinField.Bytes))
+				}
+				amount.Add(amount, coinAmount)
+			}
+		}
+	}
+	return to, amount, nil
+}
+
+// CosmosAminoSignBytes returns the bytes signed in SIGN_MODE_LEGACY_AMINO_JSON:
+// the StdSignDoc as JSON with sorted keys and no whitespace.
+func CosmosAminoSignBytes(signDoc map[string]interface{}) ([]byte, error) {
+	for _, key := range []string{"account_number", "chain_id", "fee", "msgs", "sequence"} {
+		if _, ok := signDoc[key]; !ok {
+			return nil, fmt.Errorf("invalid cosmos amino sign doc: missing %v", key)
+		}
+	}
+
+	// encoding/json sorts the keys of maps and escapes <, > and &, as the
+	// Cosmos SDK does when sorting the JSON of the StdSignDoc.
+	return json.Marshal(signDoc)
+}
+
+// CosmosAminoMessages returns the messages of an amino-JSON StdSignDoc.
+// The recipient and the amount are read from the bank MsgSend messages,
+// summing their coins.
+func CosmosAminoMessages(signDoc map[string]interface{}) ([]CosmosMsg, error) {
+	rawMsgs, ok := signDoc["msgs"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid cosmos amino sign doc: msgs is not a list")
+	}
+
+	var msgs []CosmosMsg
+	for _, rawMsg := range rawMsgs {
+		m, ok := rawMsg.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid cosmos amino message: %v", rawMsg)
+		}
+
+		var msg CosmosMsg
+		msg.Type, _ = m["type"].(string)
+		if msg.Type == cosmosMsgSendType {
+			value, _ := m["value"].(map[string]interface{})
+			msg.To, _ = value["to_address"].(string)
+			coins, ok := value["amount"].([]interface{})
+			if msg.To == "" || !ok {
+				return nil, fmt.Errorf("invalid cosmos amino MsgSend: %v", rawMsg)
+			}
+
+			msg.Amount = new(big.Int)
+			for _, coin := range coins {
+				c, _ := coin.(map[string]interface{})
+				coinAmount, ok := new(big.Int).SetString(fmt.Sprint(c["amount"]), 10)
+				if !ok {
+					return nil, fmt.Errorf("invalid cosmos coin amount: %v", c["amount"])
+				}
+				msg.Amount.Add(msg.Amount, coinAmount)
+			}
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// CosmosSignHash returns the SHA-256 of the sign bytes, which Cosmos signs.
+func CosmosSignHash(signBytes []byte) []byte {
+	digest := sha256.Sum256(signBytes)
+	return digest[:]
+}
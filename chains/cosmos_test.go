@@ -0,0 +1,107 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosmosAddress(t *testing.T) {
+	// The first account of the "abandon ... about" mnemonic on m/44'/118'/0'/0/0.
+	pubKeyBytes, _ := hex.DecodeString("024f4e2ad99c34d60b9ba6283c9431a8418af8673212961f97a77b6377fcd05b62")
+	pubKey, err := secp256k1.ParsePubKey(pubKeyBytes)
+	require.NoError(t, err)
+
+	chain := CosmosChain{}
+	require.Equal(t, "cosmos19rl4cm2hmr8afy4kldpxz3fka4jguq0auqdal4", chain.GetPublicKeyAddress(chain.SerializePublicKey(pubKey)))
+
+	address, err := CosmosAddress(pubKey, "osmo")
+	require.NoError(t, err)
+	require.Equal(t, "osmo1", address[:5])
+
+	_, err = CosmosAddress(pubKey, "Osmo")
+	require.ErrorContains(t, err, "invalid cosmos address prefix")
+}
+
+func TestCosmosTxSignAndVerify(t *testing.T) {
+	privKeyBytes, _ := hex.DecodeString("83e992df7015dcc946ab9b404b65e2a786913761e9d09f45675e9dccd1a47a2e")
+	chain := CosmosChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	var coin, msgSend, msgAny, body []byte
+	coin = appendProtoBytes(coin, 1, []byte("uatom"))
+	coin = appendProtoBytes(coin, 2, []byte("1500"))
+	msgSend = appendProtoBytes(msgSend, 1, []byte("cosmos1from"))
+	msgSend = appendProtoBytes(msgSend, 2, []byte("cosmos1to"))
+	msgSend = appendProtoBytes(msgSend, 3, coin)
+	msgSend = appendProtoBytes(msgSend, 3, coin)
+	msgAny = appendProtoBytes(msgAny, 1, []byte(cosmosMsgSendTypeURL))
+	msgAny = appendProtoBytes(msgAny, 2, msgSend)
+	body = appendProtoBytes(body, 1, msgAny)
+	body = appendProtoBytes(body, 2, []byte("memo"))
+
+	doc := &CosmosSignDoc{
+		BodyBytes:     body,
+		AuthInfoBytes: []byte{0x0a, 0x00},
+		ChainID:       "cosmoshub-4",
+		AccountNumber: 42,
+	}
+
+	parsed, err := ParseCosmosSignDoc(doc.Marshal())
+	require.NoError(t, err)
+	require.Equal(t, doc, parsed)
+
+	msgs, err := parsed.Messages()
+	require.NoError(t, err)
+	require.Equal(t, []CosmosMsg{{Type: cosmosMsgSendTypeURL, To: "cosmos1to", Amount: big.NewInt(3000)}}, msgs)
+
+	signHash := CosmosSignHash(doc.Marshal())
+	signature, err := chain.SignCompact(signHash)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Len(t, sigBytes, 64)
+
+	_, err = chain.VerifySignature(signHash, sigBytes, chain.PrivateKey.PubKey())
+	require.NoError(t, err)
+
+	_, err = chain.VerifySignature(signHash, sigBytes, nil)
+	require.ErrorContains(t, err, "missing public key")
+
+	doc.AccountNumber = 43
+	_, err = chain.VerifySignature(CosmosSignHash(doc.Marshal()), sigBytes, chain.PrivateKey.PubKey())
+	require.ErrorContains(t, err, "invalid signature")
+}
+
+func TestCosmosAminoSignBytes(t *testing.T) {
+	signDoc := map[string]interface{}{
+		"sequence":       "7",
+		"memo":           "<memo>",
+		"msgs":           []interface{}{map[string]interface{}{"value": map[string]interface{}{"to_address": "cosmos1to", "amount": []interface{}{map[string]interface{}{"denom": "uatom", "amount": "10"}}}, "type": "cosmos-sdk/MsgSend"}},
+		"fee":            map[string]interface{}{"gas": "200000", "amount": []interface{}{}},
+		"chain_id":       "cosmoshub-4",
+		"account_number": "42",
+	}
+
+	signBytes, err := CosmosAminoSignBytes(signDoc)
+	require.NoError(t, err)
+	require.Equal(t, `{"account_number":"42","chain_id":"cosmoshub-4","fee":{"amount":[],"gas":"200000"},"memo":"\u003cmemo\u003e",`+
+		`"msgs":[{"type":"cosmos-sdk/MsgSend","value":{"amount":[{"amount":"10","denom":"uatom"}],"to_address":"cosmos1to"}}],"sequence":"7"}`, string(signBytes))
+
+	msgs, err := CosmosAminoMessages(signDoc)
+	require.NoError(t, err)
+	require.Equal(t, []CosmosMsg{{Type: cosmosMsgSendType, To: "cosmos1to", Amount: big.NewInt(10)}}, msgs)
+
+	_, err = CosmosAminoMessages(map[string]interface{}{
+		"msgs": []interface{}{map[string]interface{}{"type": "cosmos-sdk/MsgSend", "value": map[string]interface{}{"to_address": "cosmos1to"}}},
+	})
+	require.ErrorContains(t, err, "invalid cosmos amino MsgSend")
+
+	delete(signDoc, "fee")
+	_, err = CosmosAminoSignBytes(signDoc)
+	require.ErrorContains(t, err, "missing fee")
+}
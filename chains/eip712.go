@@ -0,0 +1,293 @@
+package chains
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const eip712DomainType = "EIP712Domain"
+
+var eip712ArrayType = regexp.MustCompile(`^(.+)\[(\d*)\]$`)
+
+// TypedDataField is a member of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is the eth_signTypedData_v4 payload.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData decodes the typed data JSON, keeping numbers exact.
+func ParseTypedData(typedDataJSON []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(typedDataJSON)))
+	decoder.UseNumber()
+
+	typedData := new(TypedData)
+	if err := decoder.Decode(typedData); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	if _, ok := typedData.Types[eip712DomainType]; !ok {
+		return nil, fmt.Errorf("invalid typed data: missing %v type", eip712DomainType)
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, fmt.Errorf("invalid typed data: unknown primaryType %v", typedData.PrimaryType)
+	}
+	return typedData, nil
+}
+
+// Hash returns the EIP-712 digest to sign:
+// keccak256("\x19\x01" || domainSeparator || hashStruct(message)).
+func (td *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := td.HashStruct(eip712DomainType, td.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("domain: %w", err)
+	}
+
+	if td.PrimaryType == eip712DomainType {
+		return Keccak256([]byte{0x19, 0x01}, domainSeparator), nil
+	}
+
+	messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+	return Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// HashStruct returns keccak256(typeHash || encodeData(data)).
+func (td *TypedData) HashStruct(typeName string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := td.encodeData(typeName, data)
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256(encoded), nil
+}
+
+// EncodeType returns the type encoding of the struct followed by its
+// referenced struct types in alphabetical order.
+func (td *TypedData) EncodeType(typeName string) string {
+	deps := map[string]bool{}
+	td.dependencies(typeName, deps)
+	delete(deps, typeName)
+
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, name := range append([]string{typeName}, sorted...) {
+		fields := make([]string, 0, len(td.Types[name]))
+		for _, field := range td.Types[name] {
+			fields = append(fields, field.Type+" "+field.Name)
+		}
+		sb.WriteString(name + "(" + strings.Join(fields, ",") + ")")
+	}
+	return sb.String()
+}
+
+func (td *TypedData) dependencies(typeName string, deps map[string]bool) {
+	typeName = eip712BaseType(typeName)
+	if deps[typeName] {
+		return
+	}
+	if _, ok := td.Types[typeName]; !ok {
+		return
+	}
+
+	deps[typeName] = true
+	for _, field := range td.Types[typeName] {
+		td.dependencies(field.Type, deps)
+	}
+}
+
+func (td *TypedData) encodeData(typeName string, data map[string]interface{}) ([]byte, error) {
+	encoded := Keccak256([]byte(td.EncodeType(typeName)))
+
+	for _, field := range td.Types[typeName] {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing %v.%v", typeName, field.Name)
+		}
+
+		fieldEncoded, err := td.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%v.%v: %w", typeName, field.Name, err)
+		}
+		encoded = append(encoded, fieldEncoded...)
+	}
+	return encoded, nil
+}
+
+func (td *TypedData) encodeValue(typeName string, value interface{}) ([]byte, error) {
+	if matches := eip712ArrayType.FindStringSubmatch(typeName); matches != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array of %v", matches[1])
+		}
+		if matches[2] != "" {
+			if size, _ := strconv.Atoi(matches[2]); size != len(items) {
+				return nil, fmt.Errorf("expected %v items, got %v", size, len(items))
+			}
+		}
+
+		var encoded []byte
+		for _, item := range items {
+			itemEncoded, err := td.encodeValue(matches[1], item)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, itemEncoded...)
+		}
+		return Keccak256(encoded), nil
+	}
+
+	if _, ok := td.Types[typeName]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected struct %v", typeName)
+		}
+		return td.HashStruct(typeName, data)
+	}
+
+	return encodeAtomicValue(typeName, value)
+}
+
+func encodeAtomicValue(typeName string, value interface{}) ([]byte, error) {
+	switch {
+	case typeName == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string")
+		}
+		return Keccak256([]byte(s)), nil
+	case typeName == "bytes":
+		b, err := decodeTypedHex(value)
+		if err != nil {
+			return nil, err
+		}
+		return Keccak256(b), nil
+	case typeName == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool")
+		}
+		if b {
+			return leftPad32(big.NewInt(1).Bytes()), nil
+		}
+		return leftPad32(nil), nil
+	case typeName == "address":
+		b, err := decodeTypedHex(value)
+		if err != nil || len(b) != 20 {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return leftPad32(b), nil
+	case strings.HasPrefix(typeName, "bytes"):
+		size, err := strconv.Atoi(typeName[len("bytes"):])
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("unknown type %v", typeName)
+		}
+		b, err := decodeTypedHex(value)
+		if err != nil || len(b) != size {
+			return nil, fmt.Errorf("invalid %v %v", typeName, value)
+		}
+		encoded := make([]byte, 32)
+		copy(encoded, b)
+		return encoded, nil
+	case strings.HasPrefix(typeName, "uint"), strings.HasPrefix(typeName, "int"):
+		signed := strings.HasPrefix(typeName, "int")
+		bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typeName, "u"), "int"))
+		if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("unknown type %v", typeName)
+		}
+		n, err := parseTypedInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTypedInteger(n, bits, signed)
+	}
+	return nil, fmt.Errorf("unknown type %v", typeName)
+}
+
+func eip712BaseType(typeName string) string {
+	for {
+		matches := eip712ArrayType.FindStringSubmatch(typeName)
+		if matches == nil {
+			return typeName
+		}
+		typeName = matches[1]
+	}
+}
+
+func decodeTypedHex(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok || !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("expected 0x-prefixed hex, got %v", value)
+	}
+	return hex.DecodeString(s[2:])
+}
+
+func parseTypedInteger(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return big.NewInt(int64(v)), nil
+	default:
+		return nil, fmt.Errorf("expected integer, got %v", value)
+	}
+
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") {
+		n, ok = n.SetString(s[2:], 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok {
+		return nil, fmt.Errorf("expected integer, got %v", value)
+	}
+	return n, nil
+}
+
+// encodeTypedInteger encodes the integer as a 32-byte two's complement word.
+func encodeTypedInteger(n *big.Int, bits int, signed bool) ([]byte, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	min, max := big.NewInt(0), new(big.Int).Sub(limit, big.NewInt(1))
+	if signed {
+		half := new(big.Int).Rsh(limit, 1)
+		min = new(big.Int).Neg(half)
+		max = new(big.Int).Sub(half, big.NewInt(1))
+	}
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return nil, fmt.Errorf("integer %v out of range", n)
+	}
+
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return leftPad32(n.Bytes()), nil
+}
+
+func leftPad32(b []byte) []byte {
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
@@ -0,0 +1,79 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// Example from EIP-712.
+const testTypedDataJSON = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(testTypedDataJSON))
+	require.NoError(t, err)
+
+	require.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", typedData.EncodeType("Mail"))
+
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain)
+	require.NoError(t, err)
+	require.Equal(t, "f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", hex.EncodeToString(domainSeparator))
+
+	messageHash, err := typedData.HashStruct("Mail", typedData.Message)
+	require.NoError(t, err)
+	require.Equal(t, "c52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", hex.EncodeToString(messageHash))
+
+	digest, err := typedData.Hash()
+	require.NoError(t, err)
+	require.Equal(t, "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hex.EncodeToString(digest))
+
+	chain := EtherChain{PrivateKey: secp256k1.PrivKeyFromBytes(Keccak256([]byte("cow")))}
+	signature, err := chain.SignCompact(digest)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Equal(t, "4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b9156201", hex.EncodeToString(sigBytes))
+}
+
+func TestTypedDataEncodeInteger(t *testing.T) {
+	encoded, err := encodeAtomicValue("int8", "-1")
+	require.NoError(t, err)
+	require.Equal(t, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", hex.EncodeToString(encoded))
+
+	_, err = encodeAtomicValue("uint8", "256")
+	require.ErrorContains(t, err, "out of range")
+}
@@ -3,7 +3,10 @@ package chains
 import (
 	b64 "encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"math/big"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 	"golang.org/x/crypto/sha3"
 )
@@ -19,13 +22,15 @@ func (c EtherChain) GetPrivateKeySerialized() []byte {
 }
 
 func (c EtherChain) GetPublicKeySerialized() []byte {
-	return c.PrivateKey.PubKey().SerializeUncompressed()
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c EtherChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeUncompressed()
 }
 
 func (c EtherChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(pubKeySerialized[1:])
-	pubKeyHash := hasher.Sum(nil)
+	pubKeyHash := Keccak256(pubKeySerialized[1:])
 
 	beginIndex := len(pubKeyHash) - ethPublicKeyHashOffset
 	address := "0x" + hex.EncodeToString(pubKeyHash[beginIndex:])
@@ -43,3 +48,136 @@ func (c EtherChain) SignCompact(msgHash []byte) (string, error) {
 	base64Sign := b64.StdEncoding.EncodeToString(compactSig)
 	return base64Sign, nil
 }
+
+func (c EtherChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	return RecoverCompact(msgHash, signature)
+}
+
+// Ethereum typed transaction envelopes.
+const (
+	EtherLegacyTxType     = 0x00
+	EtherAccessListTxType = 0x01
+	EtherDynamicFeeTxType = 0x02
+)
+
+// EtherAccessTuple is an entry of an EIP-2930 access list.
+type EtherAccessTuple struct {
+	Address     []byte
+	StorageKeys [][]byte
+}
+
+// EtherTx holds the fields of a legacy, EIP-2930 or EIP-1559 transaction.
+// A legacy transaction with a ChainID is signed as EIP-155 requires.
+type EtherTx struct {
+	Type                 byte
+	ChainID              *big.Int
+	Nonce                uint64
+	GasPrice             *big.Int
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerGas         *big.Int
+	Gas                  uint64
+	To                   []byte
+	Value                *big.Int
+	Data                 []byte
+	AccessList           []EtherAccessTuple
+
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+func (tx *EtherTx) fields() (RLPList, error) {
+	switch tx.Type {
+	case EtherLegacyTxType:
+		return RLPList{tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data}, nil
+	case EtherAccessListTxType:
+		return RLPList{tx.ChainID, tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.Data, tx.accessList()}, nil
+	case EtherDynamicFeeTxType:
+		return RLPList{tx.ChainID, tx.Nonce, tx.MaxPriorityFeePerGas, tx.MaxFeePerGas, tx.Gas, tx.To, tx.Value, tx.Data, tx.accessList()}, nil
+	}
+	return nil, fmt.Errorf("unsupported ether tx type: %v", tx.Type)
+}
+
+func (tx *EtherTx) accessList() RLPList {
+	list := make(RLPList, 0, len(tx.AccessList))
+	for _, tuple := range tx.AccessList {
+		keys := make(RLPList, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keys = append(keys, key)
+		}
+		list = append(list, RLPList{tuple.Address, keys})
+	}
+	return list
+}
+
+func (tx *EtherTx) envelope(fields RLPList) ([]byte, error) {
+	encoded, err := RLPEncode(fields)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Type == EtherLegacyTxType {
+		return encoded, nil
+	}
+	return append([]byte{tx.Type}, encoded...), nil
+}
+
+// SigningHash returns the Keccak-256 hash of the unsigned transaction.
+func (tx *EtherTx) SigningHash() ([]byte, error) {
+	fields, err := tx.fields()
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Type == EtherLegacyTxType && tx.ChainID != nil && tx.ChainID.Sign() > 0 {
+		fields = append(fields, tx.ChainID, uint64(0), uint64(0))
+	}
+
+	encoded, err := tx.envelope(fields)
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256(encoded), nil
+}
+
+// SetSignature sets V, R and S from a <32-byte R><32-byte S><1-byte recovery code> signature.
+func (tx *EtherTx) SetSignature(signature []byte) error {
+	if len(signature) != compactSignatureLength {
+		return fmt.Errorf("invalid signature length: %v", len(signature))
+	}
+
+	tx.R = new(big.Int).SetBytes(signature[:32])
+	tx.S = new(big.Int).SetBytes(signature[32:64])
+	tx.V = big.NewInt(int64(signature[64]))
+
+	if tx.Type == EtherLegacyTxType {
+		if tx.ChainID != nil && tx.ChainID.Sign() > 0 {
+			// EIP-155: v = recovery code + chainId * 2 + 35
+			tx.V.Add(tx.V, new(big.Int).Add(new(big.Int).Lsh(tx.ChainID, 1), big.NewInt(35)))
+		} else {
+			tx.V.Add(tx.V, big.NewInt(compactMagicOffset))
+		}
+	}
+	return nil
+}
+
+// RawTransaction returns the signed transaction as sent with eth_sendRawTransaction.
+func (tx *EtherTx) RawTransaction() ([]byte, error) {
+	if tx.V == nil {
+		return nil, fmt.Errorf("ether tx not signed")
+	}
+
+	fields, err := tx.fields()
+	if err != nil {
+		return nil, err
+	}
+	return tx.envelope(append(fields, tx.V, tx.R, tx.S))
+}
+
+// Keccak256 returns the legacy Keccak-256 hash used by Ethereum.
+func Keccak256(data ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		hasher.Write(b)
+	}
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRLPEncode(t *testing.T) {
+	testCases := []struct {
+		item     interface{}
+		expected string
+	}{
+		{[]byte("dog"), "83646f67"},
+		{RLPList{"cat", "dog"}, "c88363617483646f67"},
+		{[]byte{}, "80"},
+		{uint64(0), "80"},
+		{uint64(15), "0f"},
+		{uint64(1024), "820400"},
+		{RLPList{}, "c0"},
+		{"Lorem ipsum dolor sit amet, consectetur adipisicing elit",
+			"b8384c6f72656d20697073756d20646f6c6f722073697420616d65742c20636f6e7365637465747572206164697069736963696e6720656c6974"},
+	}
+
+	for _, tc := range testCases {
+		encoded, err := RLPEncode(tc.item)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, hex.EncodeToString(encoded))
+
+		decoded, err := RLPDecode(encoded)
+		require.NoError(t, err)
+		reencoded, err := RLPEncode(decoded)
+		require.NoError(t, err)
+		require.Equal(t, encoded, reencoded)
+	}
+
+	for encoded, expected := range map[string]string{
+		"":         "unexpected end",
+		"83646f":   "unexpected end",
+		"8100":     "non-canonical",
+		"b80161":   "invalid length",
+		"c4826162": "unexpected end",
+		"0f0f":     "trailing bytes",
+	} {
+		b, _ := hex.DecodeString(encoded)
+		_, err := RLPDecode(b)
+		require.ErrorContains(t, err, expected, encoded)
+	}
+}
+
+func TestEtherTxSignEIP155(t *testing.T) {
+	// Example from EIP-155.
+	privKeyBytes, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	chain := EtherChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	to, _ := hex.DecodeString("3535353535353535353535353535353535353535")
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := &EtherTx{
+		Type:     EtherLegacyTxType,
+		ChainID:  big.NewInt(1),
+		Nonce:    9,
+		GasPrice: big.NewInt(20000000000),
+		Gas:      21000,
+		To:       to,
+		Value:    value,
+	}
+
+	hash, err := tx.SigningHash()
+	require.NoError(t, err)
+	require.Equal(t, "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53", hex.EncodeToString(hash))
+
+	signature, err := chain.SignCompact(hash)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.NoError(t, tx.SetSignature(sigBytes))
+
+	rawTx, err := tx.RawTransaction()
+	require.NoError(t, err)
+
+	expected := "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000" +
+		"8025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	require.Equal(t, expected, hex.EncodeToString(rawTx))
+}
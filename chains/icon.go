@@ -3,7 +3,9 @@ package chains
 import (
 	b64 "encoding/base64"
 	"encoding/hex"
+	"fmt"
 
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 	"golang.org/x/crypto/sha3"
 )
@@ -12,6 +14,8 @@ const (
 	publicKeyHashOffset = 20
 	// https://github.com/decred/dcrd/blob/dcrec/secp256k1/v4.2.0/dcrec/secp256k1/ecdsa/signature.go#L738
 	compactMagicOffset = 27
+
+	compactSignatureLength = 65
 )
 
 type IconChain BaseChain
@@ -21,7 +25,11 @@ func (c IconChain) GetPrivateKeySerialized() []byte {
 }
 
 func (c IconChain) GetPublicKeySerialized() []byte {
-	return c.PrivateKey.PubKey().SerializeUncompressed()
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c IconChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeUncompressed()
 }
 
 func (c IconChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
@@ -44,6 +52,24 @@ func (c IconChain) SignCompact(msgHash []byte) (string, error) {
 	return base64Sign, nil
 }
 
+// RecoverCompact recovers the public key from a <32-byte R><32-byte S><1-byte recovery code> signature.
+func RecoverCompact(msgHash []byte, signature []byte) (*secp256k1.PublicKey, error) {
+	if len(signature) != compactSignatureLength {
+		return nil, fmt.Errorf("invalid signature length: %v", len(signature))
+	}
+
+	// Ethereum wallets return the recovery code offset by 27.
+	if signature[64] >= compactMagicOffset {
+		signature = append(signature[:64:64], signature[64]-compactMagicOffset)
+	}
+
+	pubKey, _, err := ecdsa.RecoverCompact(rearrangeSignature(signature, false), msgHash)
+	if err != nil {
+		return nil, err
+	}
+	return pubKey, nil
+}
+
 // rearrangeSignature
 //
 // reverse true: <32-byte R><32-byte S><1-byte compact sig recovery code>
@@ -64,3 +90,7 @@ func rearrangeSignature(signature []byte, reverse bool) []byte {
 
 	return newSignature
 }
+
+func (c IconChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	return RecoverCompact(msgHash, signature)
+}
@@ -70,3 +70,22 @@ func TestIconTxSignCompactSerialized(t *testing.T) {
 
 	require.Equalf(t, publicKey, pubKey, "recovered publicKey: expected %x, actual=%x", publicKey.SerializeCompressed(), pubKey.SerializeCompressed())
 }
+
+func TestIconVerifySignature(t *testing.T) {
+	privKeyBytes, _ := hex.DecodeString("1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c")
+	chain := IconChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	messageHash := sha3.Sum256([]byte("icx_sendTransaction.nid.0x7"))
+	signature, err := chain.SignCompact(messageHash[:])
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+
+	pubKey, err := chain.VerifySignature(messageHash[:], sigBytes, nil)
+	require.NoError(t, err)
+	require.Equal(t, "hx5443d0db003fd7202046bbf31eaeade60af20c41", chain.GetPublicKeyAddress(chain.SerializePublicKey(pubKey)))
+
+	_, err = chain.VerifySignature(messageHash[:], sigBytes[:64], nil)
+	require.ErrorContains(t, err, "invalid signature length")
+}
@@ -0,0 +1,411 @@
+package chains
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// KaiaChain signs Kaia transactions. Its addresses are Ethereum addresses.
+type KaiaChain BaseChain
+
+func (c KaiaChain) GetPrivateKeySerialized() []byte {
+	return c.PrivateKey.Serialize()
+}
+
+func (c KaiaChain) GetPublicKeySerialized() []byte {
+	return EtherChain(c).GetPublicKeySerialized()
+}
+
+func (c KaiaChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return EtherChain(c).SerializePublicKey(pubKey)
+}
+
+func (c KaiaChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
+	return EtherChain(c).GetPublicKeyAddress(pubKeySerialized)
+}
+
+func (c KaiaChain) SignCompact(msgHash []byte) (string, error) {
+	return EtherChain(c).SignCompact(msgHash)
+}
+
+func (c KaiaChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	return RecoverCompact(msgHash, signature)
+}
+
+// Kaia transaction types. The fee-delegated variant of a type is the type
+// plus one, the variant sharing the fee at a ratio is the type plus two.
+const (
+	KaiaValueTransferTxType                               = 0x08
+	KaiaFeeDelegatedValueTransferTxType                   = 0x09
+	KaiaFeeDelegatedValueTransferWithRatioTxType          = 0x0a
+	KaiaValueTransferMemoTxType                           = 0x10
+	KaiaFeeDelegatedValueTransferMemoTxType               = 0x11
+	KaiaFeeDelegatedValueTransferMemoWithRatioTxType      = 0x12
+	KaiaSmartContractExecutionTxType                      = 0x30
+	KaiaFeeDelegatedSmartContractExecutionTxType          = 0x31
+	KaiaFeeDelegatedSmartContractExecutionWithRatioTxType = 0x32
+
+	kaiaFeeDelegated          = 0x01
+	kaiaFeeDelegatedWithRatio = 0x02
+	kaiaAddressLength         = 20
+)
+
+// KaiaTx is a Kaia value transfer, value transfer with memo or smart
+// contract execution transaction, possibly fee-delegated.
+type KaiaTx struct {
+	Type     byte
+	ChainID  *big.Int
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       []byte
+	Value    *big.Int
+	From     []byte
+	// Input is the memo or the contract call data, unused by value transfers.
+	Input []byte
+	// FeeRatio is the percentage of the fee the fee payer pays, from 1 to 99.
+	FeeRatio uint64
+
+	// Signatures are the signatures of the keys of the sender, several
+	// when its account key is a multi-sig key.
+	Signatures         []KaiaSignature
+	FeePayer           []byte
+	FeePayerSignatures []KaiaSignature
+}
+
+// KaiaSignature is a signature of a Kaia transaction.
+// V is the recovery code + chainId * 2 + 35.
+type KaiaSignature struct {
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+// KaiaTxTypeSupported reports whether the transaction type is supported.
+func KaiaTxTypeSupported(txType byte) bool {
+	switch (&KaiaTx{Type: txType}).BaseType() {
+	case KaiaValueTransferTxType, KaiaValueTransferMemoTxType, KaiaSmartContractExecutionTxType:
+		return txType&(kaiaFeeDelegated|kaiaFeeDelegatedWithRatio) != kaiaFeeDelegated|kaiaFeeDelegatedWithRatio
+	}
+	return false
+}
+
+// BaseType returns the type the transaction type is a fee-delegated variant of,
+// or the type itself.
+func (tx *KaiaTx) BaseType() byte {
+	return tx.Type &^ (kaiaFeeDelegated | kaiaFeeDelegatedWithRatio)
+}
+
+// FeeDelegated reports whether a fee payer pays the fee of the transaction.
+func (tx *KaiaTx) FeeDelegated() bool {
+	return tx.Type&(kaiaFeeDelegated|kaiaFeeDelegatedWithRatio) != 0
+}
+
+func (tx *KaiaTx) withRatio() bool {
+	return tx.Type&kaiaFeeDelegatedWithRatio != 0
+}
+
+// HasInput reports whether the transaction type has an input field.
+func (tx *KaiaTx) HasInput() bool {
+	return tx.BaseType() != KaiaValueTransferTxType
+}
+
+// fields returns the fields both the sender and the fee payer sign.
+func (tx *KaiaTx) fields() (RLPList, error) {
+	if !KaiaTxTypeSupported(tx.Type) {
+		return nil, fmt.Errorf("unsupported kaia tx type: %v", tx.Type)
+	}
+	if len(tx.To) != kaiaAddressLength || len(tx.From) != kaiaAddressLength {
+		return nil, fmt.Errorf("invalid kaia tx: missing to or from")
+	}
+
+	fields := RLPList{uint64(tx.Type), tx.Nonce, tx.GasPrice, tx.Gas, tx.To, tx.Value, tx.From}
+	if tx.HasInput() {
+		fields = append(fields, tx.Input)
+	}
+	if tx.withRatio() {
+		if tx.FeeRatio < 1 || tx.FeeRatio > 99 {
+			return nil, fmt.Errorf("invalid kaia tx fee ratio: %v", tx.FeeRatio)
+		}
+		fields = append(fields, tx.FeeRatio)
+	}
+	return fields, nil
+}
+
+func (tx *KaiaTx) signingHash(feePayer []byte) ([]byte, error) {
+	if tx.ChainID == nil {
+		return nil, fmt.Errorf("missing kaia tx chain id")
+	}
+
+	fields, err := tx.fields()
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := RLPEncode(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	sigFields := RLPList{encoded}
+	if feePayer != nil {
+		sigFields = append(sigFields, feePayer)
+	}
+	sigRLP, err := RLPEncode(append(sigFields, tx.ChainID, uint64(0), uint64(0)))
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256(sigRLP), nil
+}
+
+// SigningHash returns the Keccak-256 hash of the transaction the sender signs.
+func (tx *KaiaTx) SigningHash() ([]byte, error) {
+	return tx.signingHash(nil)
+}
+
+// FeePayerSigningHash returns the Keccak-256 hash of the transaction the fee payer signs.
+func (tx *KaiaTx) FeePayerSigningHash() ([]byte, error) {
+	if !tx.FeeDelegated() {
+		return nil, fmt.Errorf("kaia tx type %v is not fee-delegated", tx.Type)
+	}
+	if len(tx.FeePayer) != kaiaAddressLength {
+		return nil, fmt.Errorf("missing kaia tx fee payer")
+	}
+	return tx.signingHash(tx.FeePayer)
+}
+
+// kaiaSignature converts a <32-byte R><32-byte S><1-byte recovery code> signature.
+func (tx *KaiaTx) kaiaSignature(signature []byte) (KaiaSignature, error) {
+	if len(signature) != compactSignatureLength {
+		return KaiaSignature{}, fmt.Errorf("invalid signature length: %v", len(signature))
+	}
+	if tx.ChainID == nil {
+		return KaiaSignature{}, fmt.Errorf("missing kaia tx chain id")
+	}
+
+	v := new(big.Int).Add(new(big.Int).Lsh(tx.ChainID, 1), big.NewInt(35))
+	return KaiaSignature{
+		V: v.Add(v, big.NewInt(int64(signature[64]))),
+		R: new(big.Int).SetBytes(signature[:32]),
+		S: new(big.Int).SetBytes(signature[32:64]),
+	}, nil
+}
+
+// AddSignature appends a signature of the sender from a
+// <32-byte R><32-byte S><1-byte recovery code> signature.
+func (tx *KaiaTx) AddSignature(signature []byte) (KaiaSignature, error) {
+	sig, err := tx.kaiaSignature(signature)
+	if err != nil {
+		return KaiaSignature{}, err
+	}
+	tx.Signatures = append(tx.Signatures, sig)
+	return sig, nil
+}
+
+// AddFeePayerSignature appends a signature of the fee payer from a
+// <32-byte R><32-byte S><1-byte recovery code> signature.
+func (tx *KaiaTx) AddFeePayerSignature(signature []byte) (KaiaSignature, error) {
+	sig, err := tx.kaiaSignature(signature)
+	if err != nil {
+		return KaiaSignature{}, err
+	}
+	tx.FeePayerSignatures = append(tx.FeePayerSignatures, sig)
+	return sig, nil
+}
+
+// kaiaSignatures returns the signatures as an RLP list. A missing
+// signature is encoded as [1, "", ""], as the Kaia SDKs do.
+func kaiaSignatures(signatures []KaiaSignature) RLPList {
+	if len(signatures) == 0 {
+		return RLPList{RLPList{uint64(1), []byte{}, []byte{}}}
+	}
+
+	list := make(RLPList, 0, len(signatures))
+	for _, sig := range signatures {
+		list = append(list, RLPList{sig.V, sig.R, sig.S})
+	}
+	return list
+}
+
+// RawTransaction returns the transaction as sent with kaia_sendRawTransaction,
+// with the signatures collected so far.
+func (tx *KaiaTx) RawTransaction() ([]byte, error) {
+	fields, err := tx.fields()
+	if err != nil {
+		return nil, err
+	}
+
+	fields = append(fields[1:], kaiaSignatures(tx.Signatures))
+	if tx.FeeDelegated() {
+		feePayer := tx.FeePayer
+		if feePayer == nil {
+			feePayer = make([]byte, kaiaAddressLength)
+		}
+		fields = append(fields, feePayer, kaiaSignatures(tx.FeePayerSignatures))
+	}
+
+	encoded, err := RLPEncode(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.Type}, encoded...), nil
+}
+
+// ParseKaiaRawTx parses a raw transaction, such as one signed by its
+// sender to be signed by its fee payer. The chain id is taken from the
+// signatures, if any.
+func ParseKaiaRawTx(raw []byte) (*KaiaTx, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("invalid kaia tx: empty")
+	}
+	tx := &KaiaTx{Type: raw[0]}
+	if !KaiaTxTypeSupported(tx.Type) {
+		return nil, fmt.Errorf("unsupported kaia tx type: %v", tx.Type)
+	}
+
+	decoded, err := RLPDecode(raw[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid kaia tx: %w", err)
+	}
+	fields, ok := decoded.(RLPList)
+	if !ok {
+		return nil, fmt.Errorf("invalid kaia tx: not a list")
+	}
+
+	numFields := 7
+	if tx.HasInput() {
+		numFields++
+	}
+	if tx.withRatio() {
+		numFields++
+	}
+	if tx.FeeDelegated() {
+		numFields += 2
+	}
+	if len(fields) != numFields {
+		return nil, fmt.Errorf("invalid kaia tx: %v fields for type %v", len(fields), tx.Type)
+	}
+
+	r := &kaiaReader{fields: fields}
+	tx.Nonce = r.uint64()
+	tx.GasPrice = r.bigInt()
+	tx.Gas = r.uint64()
+	tx.To = r.address()
+	tx.Value = r.bigInt()
+	tx.From = r.address()
+	if tx.HasInput() {
+		tx.Input = r.bytes()
+	}
+	if tx.withRatio() {
+		tx.FeeRatio = r.uint64()
+	}
+	tx.Signatures = r.signatures()
+	if tx.FeeDelegated() {
+		tx.FeePayer = r.address()
+		tx.FeePayerSignatures = r.signatures()
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("invalid kaia tx: %w", r.err)
+	}
+
+	// A fee payer left unset by the sender is the zero address.
+	if bytes.Equal(tx.FeePayer, make([]byte, kaiaAddressLength)) {
+		tx.FeePayer = nil
+	}
+
+	for _, sig := range append(tx.Signatures, tx.FeePayerSignatures...) {
+		chainID := new(big.Int).Sub(sig.V, big.NewInt(35))
+		if chainID.Sign() < 0 {
+			return nil, fmt.Errorf("invalid kaia tx signature v: %v", sig.V)
+		}
+		chainID.Rsh(chainID, 1)
+		if tx.ChainID == nil {
+			tx.ChainID = chainID
+		} else if tx.ChainID.Cmp(chainID) != 0 {
+			return nil, fmt.Errorf("invalid kaia tx: signatures of chain ids %v and %v", tx.ChainID, chainID)
+		}
+	}
+	return tx, nil
+}
+
+// kaiaReader reads the decoded fields of a raw transaction in order.
+type kaiaReader struct {
+	fields RLPList
+	err    error
+}
+
+func (r *kaiaReader) item() interface{} {
+	item := r.fields[0]
+	r.fields = r.fields[1:]
+	return item
+}
+
+func (r *kaiaReader) bytes() []byte {
+	b, ok := r.item().([]byte)
+	if !ok && r.err == nil {
+		r.err = fmt.Errorf("unexpected list")
+	}
+	return b
+}
+
+func (r *kaiaReader) bigInt() *big.Int {
+	b := r.bytes()
+	if len(b) > 0 && b[0] == 0 && r.err == nil {
+		r.err = fmt.Errorf("integer with leading zero")
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func (r *kaiaReader) uint64() uint64 {
+	n := r.bigInt()
+	if !n.IsUint64() && r.err == nil {
+		r.err = fmt.Errorf("integer overflow")
+	}
+	return n.Uint64()
+}
+
+func (r *kaiaReader) address() []byte {
+	b := r.bytes()
+	if len(b) != kaiaAddressLength && r.err == nil {
+		r.err = fmt.Errorf("invalid address length: %v", len(b))
+	}
+	return b
+}
+
+// signatures reads a list of [v, r, s] signatures, skipping the missing ones.
+func (r *kaiaReader) signatures() []KaiaSignature {
+	list, ok := r.item().(RLPList)
+	if !ok {
+		if r.err == nil {
+			r.err = fmt.Errorf("invalid signatures")
+		}
+		return nil
+	}
+
+	var signatures []KaiaSignature
+	for _, item := range list {
+		values, ok := item.(RLPList)
+		if !ok || len(values) != 3 {
+			if r.err == nil {
+				r.err = fmt.Errorf("invalid signature")
+			}
+			return nil
+		}
+
+		sr := &kaiaReader{fields: values}
+		sig := KaiaSignature{V: sr.bigInt(), R: sr.bigInt(), S: sr.bigInt()}
+		if sr.err != nil {
+			if r.err == nil {
+				r.err = sr.err
+			}
+			return nil
+		}
+		if sig.R.Sign() == 0 && sig.S.Sign() == 0 {
+			continue
+		}
+		signatures = append(signatures, sig)
+	}
+	return signatures
+}
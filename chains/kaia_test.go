@@ -0,0 +1,130 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKaiaValueTransfer(t *testing.T) {
+	// The TxTypeValueTransfer example of the Klaytn documentation.
+	privKey, _ := hex.DecodeString("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	chain := KaiaChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKey)}
+	require.Equal(t, "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b", chain.GetPublicKeyAddress(chain.GetPublicKeySerialized()))
+
+	to, _ := hex.DecodeString("7b65b75d204abed71587c9e519a89277766ee1d0")
+	from, _ := hex.DecodeString("a94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	tx := &KaiaTx{
+		Type:     KaiaValueTransferTxType,
+		ChainID:  big.NewInt(1),
+		Nonce:    1234,
+		GasPrice: big.NewInt(0x19),
+		Gas:      0xf4240,
+		To:       to,
+		Value:    big.NewInt(0xa),
+		From:     from,
+	}
+
+	hash, err := tx.SigningHash()
+	require.NoError(t, err)
+	sigRLP, _ := hex.DecodeString("f839b5f4088204d219830f4240947b65b75d204abed71587c9e519a89277766ee1d00a94a94f5374fce5edbc8e2a8697c15331677e6ebf0b018080")
+	require.Equal(t, Keccak256(sigRLP), hash)
+
+	signature, err := chain.SignCompact(hash)
+	require.NoError(t, err)
+	sigBytes, _ := b64.StdEncoding.DecodeString(signature)
+
+	sig, err := tx.AddSignature(sigBytes)
+	require.NoError(t, err)
+	require.Equal(t, int64(0x25), sig.V.Int64())
+
+	raw, err := tx.RawTransaction()
+	require.NoError(t, err)
+	require.Equal(t, "08f87a8204d219830f4240947b65b75d204abed71587c9e519a89277766ee1d00a94a94f5374fce5edbc8e2a8697c15331677e6ebf0bf845f84325a0f3d0cd43661cabf53425535817c5058c27781f478cb5459874feaa462ed3a29aa06748abe186269ff10b8100a4b7d7fea274b53ea2905acbf498dc8b5ab1bf4fbc", hex.EncodeToString(raw))
+
+	parsed, err := ParseKaiaRawTx(raw)
+	require.NoError(t, err)
+	require.Equal(t, tx, parsed)
+
+	_, err = tx.FeePayerSigningHash()
+	require.ErrorContains(t, err, "not fee-delegated")
+}
+
+func TestKaiaFeeDelegation(t *testing.T) {
+	sender, _ := secp256k1.GeneratePrivateKey()
+	feePayer, _ := secp256k1.GeneratePrivateKey()
+	senderChain := KaiaChain{PrivateKey: sender}
+	feePayerChain := KaiaChain{PrivateKey: feePayer}
+
+	from, _ := hex.DecodeString(senderChain.GetPublicKeyAddress(senderChain.GetPublicKeySerialized())[2:])
+	feePayerAddress, _ := hex.DecodeString(feePayerChain.GetPublicKeyAddress(feePayerChain.GetPublicKeySerialized())[2:])
+	to, _ := hex.DecodeString("7b65b75d204abed71587c9e519a89277766ee1d0")
+
+	tx := &KaiaTx{
+		Type:     KaiaFeeDelegatedSmartContractExecutionWithRatioTxType,
+		ChainID:  big.NewInt(1001),
+		Nonce:    7,
+		GasPrice: big.NewInt(25000000000),
+		Gas:      100000,
+		To:       to,
+		Value:    big.NewInt(0),
+		From:     from,
+		Input:    []byte{0xa9, 0x05, 0x9c, 0xbb},
+		FeeRatio: 30,
+	}
+
+	// The sender signs first, leaving the fee payer unset.
+	hash, err := tx.SigningHash()
+	require.NoError(t, err)
+	signature, err := senderChain.SignCompact(hash)
+	require.NoError(t, err)
+	sigBytes, _ := b64.StdEncoding.DecodeString(signature)
+	_, err = tx.AddSignature(sigBytes)
+	require.NoError(t, err)
+
+	raw, err := tx.RawTransaction()
+	require.NoError(t, err)
+
+	parsed, err := ParseKaiaRawTx(raw)
+	require.NoError(t, err)
+	require.Nil(t, parsed.FeePayer)
+	require.Empty(t, parsed.FeePayerSignatures)
+	require.Equal(t, tx.Signatures, parsed.Signatures)
+	require.Equal(t, big.NewInt(1001), parsed.ChainID)
+
+	_, err = parsed.FeePayerSigningHash()
+	require.ErrorContains(t, err, "missing kaia tx fee payer")
+
+	// The fee payer signs the transaction of the sender.
+	parsed.FeePayer = feePayerAddress
+	feePayerHash, err := parsed.FeePayerSigningHash()
+	require.NoError(t, err)
+	require.NotEqual(t, hash, feePayerHash)
+
+	signature, err = feePayerChain.SignCompact(feePayerHash)
+	require.NoError(t, err)
+	sigBytes, _ = b64.StdEncoding.DecodeString(signature)
+	recovered, err := feePayerChain.VerifySignature(feePayerHash, sigBytes, nil)
+	require.NoError(t, err)
+	require.True(t, recovered.IsEqual(feePayer.PubKey()))
+
+	sig, err := parsed.AddFeePayerSignature(sigBytes)
+	require.NoError(t, err)
+	require.Equal(t, int64(1001*2+35+int(sigBytes[64])), sig.V.Int64())
+
+	raw, err = parsed.RawTransaction()
+	require.NoError(t, err)
+	signed, err := ParseKaiaRawTx(raw)
+	require.NoError(t, err)
+	require.Equal(t, parsed, signed)
+
+	_, err = ParseKaiaRawTx(append([]byte{0x0b}, raw[1:]...))
+	require.ErrorContains(t, err, "unsupported kaia tx type")
+
+	_, err = ParseKaiaRawTx(append([]byte{KaiaFeeDelegatedSmartContractExecutionTxType}, raw[1:]...))
+	require.ErrorContains(t, err, "fields for type")
+}
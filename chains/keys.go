@@ -0,0 +1,138 @@
+package chains
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// KeyType is the signature scheme of the keys of a chain.
+type KeyType string
+
+const (
+	KeySecp256k1 KeyType = "secp256k1"
+	KeyEd25519   KeyType = "ed25519"
+)
+
+// chainKeyTypes are the key types of the chains not signing with secp256k1.
+var chainKeyTypes = map[ChainName]KeyType{
+	SOLANA: KeyEd25519,
+}
+
+// ChainKeyType returns the key type of the chain.
+func ChainKeyType(chainName ChainName) KeyType {
+	if keyType, ok := chainKeyTypes[chainName]; ok {
+		return keyType
+	}
+	return KeySecp256k1
+}
+
+// PrivateKey is the private key of a wallet: a *secp256k1.PrivateKey or an Ed25519PrivateKey.
+type PrivateKey interface {
+	// Serialize returns the 32 bytes the key is stored as, the scalar of
+	// secp256k1 keys and the seed of ed25519 keys.
+	Serialize() []byte
+	// Zero clears the key from memory.
+	Zero()
+}
+
+// Ed25519PrivateKey is an ed25519 private key, its seed followed by its public key.
+type Ed25519PrivateKey ed25519.PrivateKey
+
+func (k Ed25519PrivateKey) Serialize() []byte {
+	return ed25519.PrivateKey(k).Seed()
+}
+
+func (k Ed25519PrivateKey) Zero() {
+	for i := range k {
+		k[i] = 0
+	}
+}
+
+// PublicKey returns the 32-byte public key.
+func (k Ed25519PrivateKey) PublicKey() ed25519.PublicKey {
+	return ed25519.PrivateKey(k).Public().(ed25519.PublicKey)
+}
+
+// Signer holds the key of a wallet and signs for a chain, whatever its key type.
+// Chains of secp256k1 keys sign 32-byte hashes, chains of ed25519 keys
+// sign the message itself.
+type Signer interface {
+	GetPrivateKeySerialized() []byte
+	GetPublicKeySerialized() []byte
+	GetPublicKeyAddress(b []byte) string
+	SignCompact(msg []byte) (string, error)
+}
+
+// NewSigner returns the signer of the chain holding the private key,
+// which must be of the key type of the chain.
+func NewSigner(chainName ChainName, privateKey PrivateKey) (Signer, error) {
+	switch key := privateKey.(type) {
+	case *secp256k1.PrivateKey:
+		if ChainKeyType(chainName) == KeySecp256k1 {
+			return NewChain(chainName, key)
+		}
+	case Ed25519PrivateKey:
+		if chainName == SOLANA {
+			return SolanaChain{PrivateKey: key}, nil
+		}
+	}
+	return nil, fmt.Errorf("%v keys are not supported for %v", KeyTypeOf(privateKey), chainName)
+}
+
+// KeyTypeOf returns the key type of the private key.
+func KeyTypeOf(privateKey PrivateKey) KeyType {
+	if _, ok := privateKey.(Ed25519PrivateKey); ok {
+		return KeyEd25519
+	}
+	return KeySecp256k1
+}
+
+// GeneratePrivateKey returns a new random private key of the key type.
+func GeneratePrivateKey(keyType KeyType) (PrivateKey, error) {
+	switch keyType {
+	case KeySecp256k1:
+		return secp256k1.GeneratePrivateKey()
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return Ed25519PrivateKey(key), nil
+	}
+	return nil, fmt.Errorf("unknown key type: %v", keyType)
+}
+
+// ParsePrivateKey parses a private key of the key type. Ed25519 keys are
+// given as their 32-byte seed, or as the 64-byte seed and public key
+// pair Solana wallets export.
+func ParsePrivateKey(keyType KeyType, b []byte) (PrivateKey, error) {
+	switch keyType {
+	case KeySecp256k1:
+		if len(b) != secp256k1.PrivKeyBytesLen {
+			return nil, fmt.Errorf("invalid privateKey length: %v", len(b))
+		}
+
+		var key secp256k1.ModNScalar
+		if overflow := key.SetByteSlice(b); overflow || key.IsZero() {
+			return nil, fmt.Errorf("invalid privateKey")
+		}
+		return secp256k1.NewPrivateKey(&key), nil
+
+	case KeyEd25519:
+		switch len(b) {
+		case ed25519.SeedSize:
+			return Ed25519PrivateKey(ed25519.NewKeyFromSeed(b)), nil
+		case ed25519.PrivateKeySize:
+			key := Ed25519PrivateKey(ed25519.NewKeyFromSeed(b[:ed25519.SeedSize]))
+			if !key.PublicKey().Equal(ed25519.PublicKey(b[ed25519.SeedSize:])) {
+				return nil, fmt.Errorf("invalid privateKey, public key mismatch")
+			}
+			return key, nil
+		}
+		return nil, fmt.Errorf("invalid privateKey length: %v", len(b))
+	}
+	return nil, fmt.Errorf("unknown key type: %v", keyType)
+}
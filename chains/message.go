@@ -0,0 +1,36 @@
+package chains
+
+import (
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// messagePrefixes are prepended, followed by the message length, to
+// human-readable messages before hashing, so that they cannot be
+// mistaken for transactions.
+var messagePrefixes = map[ChainName]string{
+	ETHER: "\x19Ethereum Signed Message:\n",
+	ICON:  "\x19ICON Signed Message:\n",
+	TRON:  "\x19TRON Signed Message:\n",
+	KAIA:  "\x19Klaytn Signed Message:\n",
+}
+
+// MessageHash returns the hash of the prefixed message, using the hash
+// function of the chain.
+func MessageHash(chainName ChainName, message []byte) ([]byte, error) {
+	prefix, ok := messagePrefixes[chainName]
+	if !ok {
+		return nil, fmt.Errorf("message signing not supported for %v", chainName)
+	}
+
+	prefixed := append([]byte(prefix+strconv.Itoa(len(message))), message...)
+
+	switch chainName {
+	case ETHER, TRON, KAIA:
+		return Keccak256(prefixed), nil
+	}
+	digest := sha3.Sum256(prefixed)
+	return digest[:], nil
+}
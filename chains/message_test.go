@@ -0,0 +1,20 @@
+package chains
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageHash(t *testing.T) {
+	hash, err := MessageHash(ETHER, []byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750", hex.EncodeToString(hash))
+
+	_, err = MessageHash(AERGO, []byte("hello"))
+	require.ErrorContains(t, err, "not supported")
+
+	_, err = MessageHash("solana", []byte("hello"))
+	require.ErrorContains(t, err, "not supported")
+}
@@ -0,0 +1,89 @@
+package chains
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protobuf wire types.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoField is a field of a protobuf message, as read from the wire
+// without its schema. Varint and fixed fields set Varint, the
+// length-delimited ones set Bytes.
+type protoField struct {
+	Number   uint64
+	WireType uint64
+	Varint   uint64
+	Bytes    []byte
+}
+
+// parseProtoFields reads the fields of a serialized protobuf message in wire order.
+func parseProtoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid protobuf field key")
+		}
+		b = b[n:]
+
+		field := protoField{Number: key >> 3, WireType: key & 7}
+		if field.Number == 0 {
+			return nil, fmt.Errorf("invalid protobuf field number")
+		}
+
+		switch field.WireType {
+		case protoVarint:
+			if field.Varint, n = binary.Uvarint(b); n <= 0 {
+				return nil, fmt.Errorf("invalid protobuf varint of field %v", field.Number)
+			}
+			b = b[n:]
+		case protoFixed64:
+			if len(b) < 8 {
+				return nil, fmt.Errorf("invalid protobuf fixed64 of field %v", field.Number)
+			}
+			field.Varint, b = binary.LittleEndian.Uint64(b), b[8:]
+		case protoFixed32:
+			if len(b) < 4 {
+				return nil, fmt.Errorf("invalid protobuf fixed32 of field %v", field.Number)
+			}
+			field.Varint, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case protoBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return nil, fmt.Errorf("invalid protobuf length of field %v", field.Number)
+			}
+			field.Bytes, b = b[n:n+int(length)], b[n+int(length):]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type %v of field %v", field.WireType, field.Number)
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// appendProtoVarint appends a varint field, omitted when zero as in proto3.
+func appendProtoVarint(b []byte, number uint64, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, number<<3|protoVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendProtoBytes appends a length-delimited field, omitted when empty as in proto3.
+func appendProtoBytes(b []byte, number uint64, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, number<<3|protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,130 @@
+package chains
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// RLPList is an RLP list of items.
+type RLPList []interface{}
+
+// RLPEncode returns the recursive length prefix encoding of the item.
+// Items are []byte, string, uint64, *big.Int or RLPList.
+func RLPEncode(item interface{}) ([]byte, error) {
+	switch v := item.(type) {
+	case []byte:
+		if len(v) == 1 && v[0] < 0x80 {
+			return []byte{v[0]}, nil
+		}
+		return append(rlpHeader(0x80, len(v)), v...), nil
+	case string:
+		return RLPEncode([]byte(v))
+	case uint64:
+		return RLPEncode(new(big.Int).SetUint64(v))
+	case *big.Int:
+		if v == nil {
+			return RLPEncode([]byte{})
+		}
+		if v.Sign() < 0 {
+			return nil, fmt.Errorf("rlp: negative integer %v", v)
+		}
+		return RLPEncode(v.Bytes())
+	case RLPList:
+		var payload []byte
+		for _, elem := range v {
+			encoded, err := RLPEncode(elem)
+			if err != nil {
+				return nil, err
+			}
+			payload = append(payload, encoded...)
+		}
+		return append(rlpHeader(0xc0, len(payload)), payload...), nil
+	}
+	return nil, fmt.Errorf("rlp: unsupported type %T", item)
+}
+
+func rlpHeader(offset byte, length int) []byte {
+	if length < 56 {
+		return []byte{offset + byte(length)}
+	}
+
+	lengthBytes := binary.BigEndian.AppendUint64(nil, uint64(length))
+	for len(lengthBytes) > 1 && lengthBytes[0] == 0 {
+		lengthBytes = lengthBytes[1:]
+	}
+	return append([]byte{offset + 55 + byte(len(lengthBytes))}, lengthBytes...)
+}
+
+// RLPDecode decodes a single RLP item into []byte strings and RLPList lists.
+func RLPDecode(b []byte) (interface{}, error) {
+	item, rest, err := rlpDecodeItem(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("rlp: %v trailing bytes", len(rest))
+	}
+	return item, nil
+}
+
+func rlpDecodeItem(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("rlp: unexpected end")
+	}
+
+	prefix := b[0]
+	switch {
+	case prefix < 0x80:
+		return b[:1:1], b[1:], nil
+	case prefix < 0xc0:
+		payload, rest, err := rlpPayload(b, 0x80)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(payload) == 1 && payload[0] < 0x80 {
+			return nil, nil, fmt.Errorf("rlp: non-canonical single byte")
+		}
+		return payload, rest, nil
+	}
+
+	payload, rest, err := rlpPayload(b, 0xc0)
+	if err != nil {
+		return nil, nil, err
+	}
+	list := RLPList{}
+	for len(payload) > 0 {
+		var item interface{}
+		if item, payload, err = rlpDecodeItem(payload); err != nil {
+			return nil, nil, err
+		}
+		list = append(list, item)
+	}
+	return list, rest, nil
+}
+
+// rlpPayload splits the payload of the string or list at the start of b from the bytes following it.
+func rlpPayload(b []byte, offset byte) ([]byte, []byte, error) {
+	length := int(b[0] - offset)
+	b = b[1:]
+
+	if length >= 56 {
+		lengthSize := length - 55
+		if lengthSize > 8 || len(b) < lengthSize || b[0] == 0 {
+			return nil, nil, fmt.Errorf("rlp: invalid length")
+		}
+		n := uint64(0)
+		for _, c := range b[:lengthSize] {
+			n = n<<8 | uint64(c)
+		}
+		if n < 56 || n > uint64(len(b)-lengthSize) {
+			return nil, nil, fmt.Errorf("rlp: invalid length")
+		}
+		b, length = b[lengthSize:], int(n)
+	}
+
+	if length > len(b) {
+		return nil, nil, fmt.Errorf("rlp: unexpected end")
+	}
+	return b[:length:length], b[length:], nil
+}
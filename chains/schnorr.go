@@ -0,0 +1,138 @@
+package chains
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	SchnorrSignatureLength = 64
+)
+
+// TaggedHash returns the BIP-340 tagged hash SHA256(SHA256(tag) || SHA256(tag) || msg...).
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	digest := sha256.New()
+	digest.Write(tagHash[:])
+	digest.Write(tagHash[:])
+	for _, msg := range msgs {
+		digest.Write(msg)
+	}
+	return digest.Sum(nil)
+}
+
+// XOnlyPublicKey returns the 32-byte x coordinate of the public key, as BIP-340 serializes it.
+func XOnlyPublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeCompressed()[1:]
+}
+
+// ParseXOnlyPublicKey returns the public key with the x coordinate and an even y coordinate.
+func ParseXOnlyPublicKey(xOnly []byte) (*secp256k1.PublicKey, error) {
+	if len(xOnly) != 32 {
+		return nil, fmt.Errorf("invalid x-only public key length: %v", len(xOnly))
+	}
+	return secp256k1.ParsePubKey(append([]byte{secp256k1.PubKeyFormatCompressedEven}, xOnly...))
+}
+
+// SchnorrSign returns the BIP-340 signature of the 32-byte msgHash.
+func SchnorrSign(privateKey *secp256k1.PrivateKey, msgHash []byte) ([]byte, error) {
+	var auxRand [32]byte
+	if _, err := rand.Read(auxRand[:]); err != nil {
+		return nil, err
+	}
+	return schnorrSign(&privateKey.Key, msgHash, auxRand[:])
+}
+
+func schnorrSign(privateKey *secp256k1.ModNScalar, msgHash []byte, auxRand []byte) ([]byte, error) {
+	if len(msgHash) != 32 {
+		return nil, fmt.Errorf("invalid hash length: %v", len(msgHash))
+	}
+	if privateKey.IsZero() {
+		return nil, fmt.Errorf("invalid private key")
+	}
+
+	// The secret key is negated when its public key has an odd y coordinate.
+	var d secp256k1.ModNScalar
+	d.Set(privateKey)
+	pubKey := scalarBasePoint(&d)
+	if pubKey.Y.IsOdd() {
+		d.Negate()
+	}
+	pubKeyX := pubKey.X.Bytes()
+
+	dBytes := d.Bytes()
+	t := TaggedHash("BIP0340/aux", auxRand)
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+
+	var k secp256k1.ModNScalar
+	k.SetByteSlice(TaggedHash("BIP0340/nonce", t, pubKeyX[:], msgHash))
+	if k.IsZero() {
+		return nil, fmt.Errorf("invalid schnorr nonce")
+	}
+
+	r := scalarBasePoint(&k)
+	if r.Y.IsOdd() {
+		k.Negate()
+	}
+	rX := r.X.Bytes()
+
+	var e secp256k1.ModNScalar
+	e.SetByteSlice(TaggedHash("BIP0340/challenge", rX[:], pubKeyX[:], msgHash))
+
+	s := new(secp256k1.ModNScalar).Mul2(&e, &d).Add(&k)
+	sBytes := s.Bytes()
+
+	return append(rX[:], sBytes[:]...), nil
+}
+
+// SchnorrVerify reports whether signature is the BIP-340 signature of msgHash
+// by the x-only public key.
+func SchnorrVerify(xOnly []byte, msgHash []byte, signature []byte) bool {
+	if len(signature) != SchnorrSignatureLength || len(msgHash) != 32 {
+		return false
+	}
+
+	pubKey, err := ParseXOnlyPublicKey(xOnly)
+	if err != nil {
+		return false
+	}
+
+	var rX secp256k1.FieldVal
+	if overflow := rX.SetByteSlice(signature[:32]); overflow {
+		return false
+	}
+	var s secp256k1.ModNScalar
+	if overflow := s.SetByteSlice(signature[32:]); overflow {
+		return false
+	}
+
+	var e secp256k1.ModNScalar
+	e.SetByteSlice(TaggedHash("BIP0340/challenge", signature[:32], xOnly, msgHash))
+
+	// R = s*G - e*P
+	var p, sG, eP, r secp256k1.JacobianPoint
+	pubKey.AsJacobian(&p)
+	secp256k1.ScalarBaseMultNonConst(&s, &sG)
+	secp256k1.ScalarMultNonConst(e.Negate(), &p, &eP)
+	secp256k1.AddNonConst(&sG, &eP, &r)
+
+	if (r.X.IsZero() && r.Y.IsZero()) || r.Z.IsZero() {
+		return false
+	}
+	r.ToAffine()
+	return !r.Y.IsOdd() && r.X.Equals(&rX)
+}
+
+// scalarBasePoint returns k*G in affine coordinates.
+func scalarBasePoint(k *secp256k1.ModNScalar) *secp256k1.JacobianPoint {
+	var point secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(k, &point)
+	point.ToAffine()
+	return &point
+}
@@ -0,0 +1,58 @@
+package chains
+
+import (
+	"crypto/ed25519"
+	b64 "encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+// SolanaChain signs Solana messages with an ed25519 key. Its addresses are
+// the base58 public keys.
+type SolanaChain struct {
+	PrivateKey Ed25519PrivateKey
+}
+
+func (c SolanaChain) GetPrivateKeySerialized() []byte {
+	return c.PrivateKey.Serialize()
+}
+
+func (c SolanaChain) GetPublicKeySerialized() []byte {
+	return c.PrivateKey.PublicKey()
+}
+
+func (c SolanaChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
+	return base58.Encode(pubKeySerialized)
+}
+
+// SignCompact returns the 64-byte ed25519 signature of the message itself.
+func (c SolanaChain) SignCompact(msg []byte) (string, error) {
+	signature := ed25519.Sign(ed25519.PrivateKey(c.PrivateKey), msg)
+
+	base64Sign := b64.StdEncoding.EncodeToString(signature)
+	return base64Sign, nil
+}
+
+// DecodeSolanaAddress returns the 32-byte public key of a base58 Solana address.
+func DecodeSolanaAddress(address string) (ed25519.PublicKey, error) {
+	decoded := base58.Decode(address)
+	if len(decoded) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid solana address: %v", address)
+	}
+	return decoded, nil
+}
+
+// VerifyEd25519 verifies the ed25519 signature of the message.
+func VerifyEd25519(message []byte, signature []byte, pubKey ed25519.PublicKey) error {
+	if len(pubKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length: %v", len(pubKey))
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature length: %v", len(signature))
+	}
+	if !ed25519.Verify(pubKey, message, signature) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
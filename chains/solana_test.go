@@ -0,0 +1,136 @@
+package chains
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolanaSignCompact(t *testing.T) {
+	// Test 1 of RFC 8032, signing the empty message.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	privateKey, err := ParsePrivateKey(KeyEd25519, seed)
+	require.NoError(t, err)
+
+	chain := SolanaChain{PrivateKey: privateKey.(Ed25519PrivateKey)}
+	require.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", hex.EncodeToString(chain.GetPublicKeySerialized()))
+	require.Equal(t, seed, chain.GetPrivateKeySerialized())
+
+	address := chain.GetPublicKeyAddress(chain.GetPublicKeySerialized())
+	pubKey, err := DecodeSolanaAddress(address)
+	require.NoError(t, err)
+	require.Equal(t, chain.GetPublicKeySerialized(), []byte(pubKey))
+
+	signature, err := chain.SignCompact(nil)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Equal(t, "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b", hex.EncodeToString(sigBytes))
+
+	require.NoError(t, VerifyEd25519(nil, sigBytes, pubKey))
+	require.ErrorContains(t, VerifyEd25519([]byte{0}, sigBytes, pubKey), "invalid signature")
+
+	keypair, err := ParsePrivateKey(KeyEd25519, append(seed, pubKey...))
+	require.NoError(t, err)
+	require.Equal(t, seed, keypair.Serialize())
+
+	_, err = ParsePrivateKey(KeyEd25519, append(seed, seed...))
+	require.ErrorContains(t, err, "public key mismatch")
+
+	_, err = NewSigner(ETHER, privateKey)
+	require.ErrorContains(t, err, "ed25519 keys are not supported for ether")
+}
+
+func TestSolanaMessage(t *testing.T) {
+	payer := bytes.Repeat([]byte{0x01}, 32)
+	recipient := bytes.Repeat([]byte{0x02}, 32)
+	table := bytes.Repeat([]byte{0x03}, 32)
+	systemProgram := make([]byte, 32)
+
+	transfer := binary.LittleEndian.AppendUint32(nil, solanaTransfer)
+	transfer = binary.LittleEndian.AppendUint64(transfer, 5000)
+
+	// A legacy transfer from the payer to the recipient.
+	legacy := testSolanaMessage(SolanaLegacyMessage, [][]byte{payer, recipient, systemProgram}, []SolanaInstruction{
+		{ProgramIDIndex: 2, Accounts: []uint8{0, 1}, Data: transfer},
+	}, nil)
+
+	m, err := ParseSolanaMessage(legacy)
+	require.NoError(t, err)
+	require.Equal(t, SolanaLegacyMessage, m.Version)
+	require.Equal(t, 0, m.SignerIndex(payer))
+	require.Equal(t, -1, m.SignerIndex(recipient))
+	require.Equal(t, []string{SolanaSystemProgram}, m.ProgramIDs())
+
+	transfers, err := m.Transfers()
+	require.NoError(t, err)
+	require.Equal(t, []SolanaTransfer{{From: base58.Encode(payer), To: base58.Encode(recipient), Lamports: 5000}}, transfers)
+
+	// A v0 transfer to the second writable account of a lookup table.
+	v0 := testSolanaMessage(0, [][]byte{payer, systemProgram}, []SolanaInstruction{
+		{ProgramIDIndex: 1, Accounts: []uint8{0, 2}, Data: transfer},
+	}, []SolanaAddressTableLookup{
+		{AccountKey: table, WritableIndexes: []uint8{7}, ReadonlyIndexes: []uint8{}},
+	})
+
+	m, err = ParseSolanaMessage(v0)
+	require.NoError(t, err)
+	require.Equal(t, 0, m.Version)
+	require.Len(t, m.AddressTableLookups, 1)
+
+	transfers, err = m.Transfers()
+	require.NoError(t, err)
+	require.Equal(t, base58.Encode(table)+":7", transfers[0].To)
+
+	_, err = ParseSolanaMessage(append(v0, 0x00))
+	require.ErrorContains(t, err, "trailing bytes")
+
+	_, err = ParseSolanaMessage(legacy[:len(legacy)-1])
+	require.ErrorContains(t, err, "unexpected end")
+
+	_, err = ParseSolanaMessage(append([]byte{0x81}, legacy...))
+	require.ErrorContains(t, err, "unsupported solana message version")
+
+	signature := bytes.Repeat([]byte{0x04}, ed25519.SignatureSize)
+	tx := SolanaTransaction(legacy, [][]byte{signature})
+	require.Equal(t, append(append([]byte{0x01}, signature...), legacy...), tx)
+}
+
+// testSolanaMessage serializes a message whose first account is the only signer.
+func testSolanaMessage(version int, accountKeys [][]byte, instructions []SolanaInstruction, lookups []SolanaAddressTableLookup) []byte {
+	var b []byte
+	if version != SolanaLegacyMessage {
+		b = append(b, solanaVersionPrefix|byte(version))
+	}
+	b = append(b, 1, 0, 1)
+
+	b = appendCompactU16(b, len(accountKeys))
+	for _, key := range accountKeys {
+		b = append(b, key...)
+	}
+	b = append(b, bytes.Repeat([]byte{0xbb}, solanaHashLength)...)
+
+	b = appendCompactU16(b, len(instructions))
+	for _, instruction := range instructions {
+		b = append(b, instruction.ProgramIDIndex)
+		b = append(appendCompactU16(b, len(instruction.Accounts)), instruction.Accounts...)
+		b = append(appendCompactU16(b, len(instruction.Data)), instruction.Data...)
+	}
+
+	if version != SolanaLegacyMessage {
+		b = appendCompactU16(b, len(lookups))
+		for _, lookup := range lookups {
+			b = append(b, lookup.AccountKey...)
+			b = append(appendCompactU16(b, len(lookup.WritableIndexes)), lookup.WritableIndexes...)
+			b = append(appendCompactU16(b, len(lookup.ReadonlyIndexes)), lookup.ReadonlyIndexes...)
+		}
+	}
+	return b
+}
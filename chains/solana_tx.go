@@ -0,0 +1,268 @@
+package chains
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+const (
+	// SolanaLegacyMessage is the version of the messages without a version prefix.
+	SolanaLegacyMessage = -1
+
+	solanaVersionPrefix = 0x80
+	solanaHashLength    = 32
+)
+
+// Instructions of the System program.
+const (
+	solanaCreateAccount    = 0
+	solanaTransfer         = 2
+	solanaTransferWithSeed = 11
+)
+
+// SolanaSystemProgram is the address of the System program moving lamports.
+var SolanaSystemProgram = base58.Encode(make([]byte, ed25519.PublicKeySize))
+
+// SolanaMessage is a legacy or v0 Solana transaction message.
+type SolanaMessage struct {
+	Version               int
+	NumRequiredSignatures uint8
+	NumReadonlySigned     uint8
+	NumReadonlyUnsigned   uint8
+	AccountKeys           [][]byte
+	RecentBlockhash       []byte
+	Instructions          []SolanaInstruction
+	AddressTableLookups   []SolanaAddressTableLookup
+}
+
+// SolanaInstruction is a compiled instruction, referring to the accounts of the message by index.
+type SolanaInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// SolanaAddressTableLookup loads accounts of a v0 message from an address lookup table.
+type SolanaAddressTableLookup struct {
+	AccountKey      []byte
+	WritableIndexes []uint8
+	ReadonlyIndexes []uint8
+}
+
+// SolanaTransfer is a movement of lamports out of an account of the message.
+type SolanaTransfer struct {
+	From     string
+	To       string
+	Lamports uint64
+}
+
+// solanaReader reads the fields of a serialized message.
+type solanaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *solanaReader) readBytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.b) {
+		r.err = fmt.Errorf("invalid solana message: unexpected end")
+		return nil
+	}
+	b := r.b[:n:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *solanaReader) readByte() uint8 {
+	if b := r.readBytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// readCompactU16 reads a compact-u16, the length prefix of Solana arrays.
+func (r *solanaReader) readCompactU16() int {
+	var v int
+	for i := 0; i < 3; i++ {
+		b := r.readByte()
+		v |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+	if r.err == nil {
+		r.err = fmt.Errorf("invalid solana message: invalid compact-u16")
+	}
+	return 0
+}
+
+func (r *solanaReader) readCompactBytes() []byte {
+	return r.readBytes(r.readCompactU16())
+}
+
+// ParseSolanaMessage parses a serialized legacy or v0 message, the bytes
+// the signatures of a Solana transaction cover.
+func ParseSolanaMessage(b []byte) (*SolanaMessage, error) {
+	r := &solanaReader{b: b}
+	m := &SolanaMessage{Version: SolanaLegacyMessage}
+
+	if len(b) > 0 && b[0]&solanaVersionPrefix != 0 {
+		if m.Version = int(r.readByte() &^ solanaVersionPrefix); m.Version != 0 {
+			return nil, fmt.Errorf("unsupported solana message version: %v", m.Version)
+		}
+	}
+
+	m.NumRequiredSignatures = r.readByte()
+	m.NumReadonlySigned = r.readByte()
+	m.NumReadonlyUnsigned = r.readByte()
+
+	numAccounts := r.readCompactU16()
+	for i := 0; i < numAccounts && r.err == nil; i++ {
+		m.AccountKeys = append(m.AccountKeys, r.readBytes(ed25519.PublicKeySize))
+	}
+	m.RecentBlockhash = r.readBytes(solanaHashLength)
+
+	numInstructions := r.readCompactU16()
+	for i := 0; i < numInstructions && r.err == nil; i++ {
+		m.Instructions = append(m.Instructions, SolanaInstruction{
+			ProgramIDIndex: r.readByte(),
+			Accounts:       r.readCompactBytes(),
+			Data:           r.readCompactBytes(),
+		})
+	}
+
+	if m.Version == 0 {
+		numLookups := r.readCompactU16()
+		for i := 0; i < numLookups && r.err == nil; i++ {
+			m.AddressTableLookups = append(m.AddressTableLookups, SolanaAddressTableLookup{
+				AccountKey:      r.readBytes(ed25519.PublicKeySize),
+				WritableIndexes: r.readCompactBytes(),
+				ReadonlyIndexes: r.readCompactBytes(),
+			})
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.b) > 0 {
+		return nil, fmt.Errorf("invalid solana message: %v trailing bytes", len(r.b))
+	}
+	if m.NumRequiredSignatures == 0 || int(m.NumRequiredSignatures) > len(m.AccountKeys) {
+		return nil, fmt.Errorf("invalid solana message: %v required signatures for %v accounts", m.NumRequiredSignatures, len(m.AccountKeys))
+	}
+	for _, instruction := range m.Instructions {
+		// Programs are never loaded from address lookup tables.
+		if int(instruction.ProgramIDIndex) >= len(m.AccountKeys) {
+			return nil, fmt.Errorf("invalid solana message: program id index %v out of range", instruction.ProgramIDIndex)
+		}
+	}
+	return m, nil
+}
+
+// Account returns the address of the account at the index of the message.
+// The accounts loaded from address lookup tables, whose addresses are not
+// in the message, are named <table address>:<index in the table>.
+func (m *SolanaMessage) Account(index uint8) string {
+	i := int(index)
+	if i < len(m.AccountKeys) {
+		return base58.Encode(m.AccountKeys[i])
+	}
+	i -= len(m.AccountKeys)
+
+	// The writable accounts of all the tables come before the read-only ones.
+	for _, lookup := range m.AddressTableLookups {
+		if i < len(lookup.WritableIndexes) {
+			return fmt.Sprintf("%v:%v", base58.Encode(lookup.AccountKey), lookup.WritableIndexes[i])
+		}
+		i -= len(lookup.WritableIndexes)
+	}
+	for _, lookup := range m.AddressTableLookups {
+		if i < len(lookup.ReadonlyIndexes) {
+			return fmt.Sprintf("%v:%v", base58.Encode(lookup.AccountKey), lookup.ReadonlyIndexes[i])
+		}
+		i -= len(lookup.ReadonlyIndexes)
+	}
+	return fmt.Sprintf("unknown:%v", index)
+}
+
+// SignerIndex returns the index of the signature of the public key in the
+// transaction, or -1 when the message does not require its signature.
+func (m *SolanaMessage) SignerIndex(pubKey ed25519.PublicKey) int {
+	for i := 0; i < int(m.NumRequiredSignatures); i++ {
+		if bytes.Equal(m.AccountKeys[i], pubKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ProgramIDs returns the distinct programs the message invokes, in order.
+func (m *SolanaMessage) ProgramIDs() []string {
+	var programIDs []string
+	seen := map[uint8]bool{}
+	for _, instruction := range m.Instructions {
+		if !seen[instruction.ProgramIDIndex] {
+			seen[instruction.ProgramIDIndex] = true
+			programIDs = append(programIDs, m.Account(instruction.ProgramIDIndex))
+		}
+	}
+	return programIDs
+}
+
+// Transfers returns the lamports the System program instructions of the
+// message move: transfers and the funding of created accounts.
+func (m *SolanaMessage) Transfers() ([]SolanaTransfer, error) {
+	var transfers []SolanaTransfer
+	for _, instruction := range m.Instructions {
+		if m.Account(instruction.ProgramIDIndex) != SolanaSystemProgram || len(instruction.Data) < 4 {
+			continue
+		}
+
+		// The index of the account receiving the lamports.
+		var to int
+		switch binary.LittleEndian.Uint32(instruction.Data) {
+		case solanaCreateAccount, solanaTransfer:
+			to = 1
+		case solanaTransferWithSeed:
+			to = 2
+		default:
+			continue
+		}
+		if len(instruction.Data) < 12 || len(instruction.Accounts) <= to {
+			return nil, fmt.Errorf("invalid solana system instruction")
+		}
+
+		transfers = append(transfers, SolanaTransfer{
+			From:     m.Account(instruction.Accounts[0]),
+			To:       m.Account(instruction.Accounts[to]),
+			Lamports: binary.LittleEndian.Uint64(instruction.Data[4:]),
+		})
+	}
+	return transfers, nil
+}
+
+// SolanaTransaction serializes a transaction of the message with its signatures,
+// in the order of the signer accounts.
+func SolanaTransaction(message []byte, signatures [][]byte) []byte {
+	var b []byte
+	b = appendCompactU16(b, len(signatures))
+	for _, signature := range signatures {
+		b = append(b, signature...)
+	}
+	return append(b, message...)
+}
+
+func appendCompactU16(b []byte, v int) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
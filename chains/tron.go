@@ -0,0 +1,229 @@
+package chains
+
+import (
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+const (
+	// TronAddressPrefix is the first byte of the 21-byte Tron addresses.
+	TronAddressPrefix = 0x41
+
+	tronAddressLength = 21
+)
+
+// Tron contract types, as in the ContractType enum of java-tron.
+const (
+	TronTransferContract      = 1
+	TronTransferAssetContract = 2
+	TronTriggerSmartContract  = 31
+)
+
+// TronContractTypes maps the Tron contract types which are decoded to their names.
+var TronContractTypes = map[uint64]string{
+	TronTransferContract:      "TransferContract",
+	TronTransferAssetContract: "TransferAssetContract",
+	TronTriggerSmartContract:  "TriggerSmartContract",
+}
+
+// TronChain derives Tron addresses, the Ethereum address of the key with the
+// 0x41 prefix encoded in base58check.
+type TronChain BaseChain
+
+func (c TronChain) GetPrivateKeySerialized() []byte {
+	return c.PrivateKey.Serialize()
+}
+
+func (c TronChain) GetPublicKeySerialized() []byte {
+	return c.SerializePublicKey(c.PrivateKey.PubKey())
+}
+
+func (c TronChain) SerializePublicKey(pubKey *secp256k1.PublicKey) []byte {
+	return pubKey.SerializeUncompressed()
+}
+
+func (c TronChain) GetPublicKeyAddress(pubKeySerialized []byte) string {
+	pubKeyHash := Keccak256(pubKeySerialized[1:])
+
+	return base58.CheckEncode(pubKeyHash[len(pubKeyHash)-ethPublicKeyHashOffset:], TronAddressPrefix)
+}
+
+// SignCompact returns the <32-byte R><32-byte S><1-byte V> signature Tron
+// expects, with V being the recovery code offset by 27.
+func (c TronChain) SignCompact(msgHash []byte) (string, error) {
+	signature := ecdsa.SignCompact(c.PrivateKey, msgHash, false)
+
+	compactSig := append(signature[1:], signature[0])
+
+	base64Sign := b64.StdEncoding.EncodeToString(compactSig)
+	return base64Sign, nil
+}
+
+func (c TronChain) VerifySignature(msgHash []byte, signature []byte, pubKey *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	return RecoverCompact(msgHash, signature)
+}
+
+// DecodeTronAddress returns the 21 bytes of a base58check Tron address.
+func DecodeTronAddress(address string) ([]byte, error) {
+	decoded, version, err := base58.CheckDecode(address)
+	if err != nil || version != TronAddressPrefix || len(decoded) != tronAddressLength-1 {
+		return nil, fmt.Errorf("invalid tron address: %v", address)
+	}
+	return append([]byte{version}, decoded...), nil
+}
+
+// EncodeTronAddress returns the base58check form of the 21 bytes of a Tron address.
+func EncodeTronAddress(address []byte) (string, error) {
+	if len(address) != tronAddressLength || address[0] != TronAddressPrefix {
+		return "", fmt.Errorf("invalid tron address: %x", address)
+	}
+	return base58.CheckEncode(address[1:], TronAddressPrefix), nil
+}
+
+// TronAddressToHex converts a base58check Tron address to its hex form, 41 followed by 20 bytes.
+func TronAddressToHex(address string) (string, error) {
+	decoded, err := DecodeTronAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(decoded), nil
+}
+
+// TronHexToAddress converts the hex form of a Tron address to base58check.
+// The 0x-prefixed 20-byte Ethereum form is accepted as well.
+func TronHexToAddress(hexAddress string) (string, error) {
+	if strings.HasPrefix(hexAddress, "0x") {
+		hexAddress = fmt.Sprintf("%x", TronAddressPrefix) + hexAddress[2:]
+	}
+
+	address, err := hex.DecodeString(hexAddress)
+	if err != nil {
+		return "", fmt.Errorf("invalid tron address: %v", hexAddress)
+	}
+	return EncodeTronAddress(address)
+}
+
+// TronTxID returns the transaction ID, the SHA-256 of the raw_data protobuf, which Tron signs.
+func TronTxID(rawData []byte) []byte {
+	digest := sha256.Sum256(rawData)
+	return digest[:]
+}
+
+// TronContract is the contract of a Tron transaction, reduced to the
+// fields signing policies are checked against. Owner is read from every
+// contract type, To, Amount and Data only from the types of
+// TronContractTypes. Amount is in sun, or in units of the asset for a
+// TransferAssetContract.
+type TronContract struct {
+	Type   uint64
+	Owner  []byte
+	To     []byte
+	Amount *big.Int
+	Data   []byte
+}
+
+// ParseTronContract parses the raw_data protobuf of a Tron transaction and
+// returns its contract. Tron transactions hold a single contract.
+func ParseTronContract(rawData []byte) (*TronContract, error) {
+	fields, err := parseProtoFields(rawData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tron raw data: %w", err)
+	}
+
+	var contracts [][]byte
+	for _, field := range fields {
+		// repeated Contract contract = 11;
+		if field.Number == 11 && field.WireType == protoBytes {
+			contracts = append(contracts, field.Bytes)
+		}
+	}
+	if len(contracts) != 1 {
+		return nil, fmt.Errorf("invalid tron raw data: expected 1 contract, got %v", len(contracts))
+	}
+
+	// Contract { ContractType type = 1; google.protobuf.Any parameter = 2; ... }
+	contractFields, err := parseProtoFields(contracts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid tron contract: %w", err)
+	}
+	contract := new(TronContract)
+	var parameter []byte
+	for _, field := range contractFields {
+		switch field.Number {
+		case 1:
+			contract.Type = field.Varint
+		case 2:
+			parameter = field.Bytes
+		}
+	}
+
+	// google.protobuf.Any { string type_url = 1; bytes value = 2; }
+	anyFields, err := parseProtoFields(parameter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tron contract parameter: %w", err)
+	}
+	var value []byte
+	for _, field := range anyFields {
+		if field.Number == 2 {
+			value = field.Bytes
+		}
+	}
+	valueFields, err := parseProtoFields(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tron contract parameter: %w", err)
+	}
+
+	for _, field := range valueFields {
+		switch contract.Type {
+		case TronTransferContract:
+			// TransferContract { bytes owner_address = 1; bytes to_address = 2; int64 amount = 3; }
+			switch field.Number {
+			case 1:
+				contract.Owner = field.Bytes
+			case 2:
+				contract.To = field.Bytes
+			case 3:
+				contract.Amount = new(big.Int).SetUint64(field.Varint)
+			}
+		case TronTransferAssetContract:
+			// TransferAssetContract { bytes asset_name = 1; bytes owner_address = 2; bytes to_address = 3; int64 amount = 4; }
+			switch field.Number {
+			case 2:
+				contract.Owner = field.Bytes
+			case 3:
+				contract.To = field.Bytes
+			case 4:
+				contract.Amount = new(big.Int).SetUint64(field.Varint)
+			}
+		case TronTriggerSmartContract:
+			// TriggerSmartContract { bytes owner_address = 1; bytes contract_address = 2; int64 call_value = 3; bytes data = 4; ... }
+			switch field.Number {
+			case 1:
+				contract.Owner = field.Bytes
+			case 2:
+				contract.To = field.Bytes
+			case 3:
+				contract.Amount = new(big.Int).SetUint64(field.Varint)
+			case 4:
+				contract.Data = field.Bytes
+			}
+		default:
+			// The other contracts start with their bytes owner_address = 1.
+			if field.Number == 1 && field.WireType == protoBytes {
+				contract.Owner = field.Bytes
+			}
+		}
+	}
+	if _, ok := TronContractTypes[contract.Type]; ok && contract.Amount == nil {
+		contract.Amount = new(big.Int)
+	}
+	return contract, nil
+}
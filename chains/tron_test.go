@@ -0,0 +1,123 @@
+package chains
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTronAddress(t *testing.T) {
+	for _, tc := range []struct {
+		address    string
+		hexAddress string
+	}{
+		{"T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb", "410000000000000000000000000000000000000000"},
+		{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"},
+	} {
+		hexAddress, err := TronAddressToHex(tc.address)
+		require.NoError(t, err)
+		require.Equal(t, tc.hexAddress, hexAddress)
+
+		address, err := TronHexToAddress(tc.hexAddress)
+		require.NoError(t, err)
+		require.Equal(t, tc.address, address)
+
+		address, err = TronHexToAddress("0x" + tc.hexAddress[2:])
+		require.NoError(t, err)
+		require.Equal(t, tc.address, address)
+	}
+
+	_, err := TronAddressToHex("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u")
+	require.ErrorContains(t, err, "invalid tron address")
+
+	_, err = TronHexToAddress("42a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	require.ErrorContains(t, err, "invalid tron address")
+
+	// The Tron address of a key is its Ethereum address with the 0x41 prefix.
+	privKeyBytes, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	chain := TronChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	hexAddress, err := TronAddressToHex(chain.GetPublicKeyAddress(chain.GetPublicKeySerialized()))
+	require.NoError(t, err)
+	require.Equal(t, "419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f", hexAddress)
+}
+
+func TestTronTxSignAndVerify(t *testing.T) {
+	privKeyBytes, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	chain := TronChain{PrivateKey: secp256k1.PrivKeyFromBytes(privKeyBytes)}
+
+	owner, _ := hex.DecodeString("419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+	to, _ := hex.DecodeString("41a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+	rawData := testTronRawData(owner, to, 1000000)
+
+	contract, err := ParseTronContract(rawData)
+	require.NoError(t, err)
+	require.Equal(t, &TronContract{Type: TronTransferContract, Owner: owner, To: to, Amount: big.NewInt(1000000)}, contract)
+
+	txID := TronTxID(rawData)
+	signature, err := chain.SignCompact(txID)
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	require.NoError(t, err)
+	require.Len(t, sigBytes, 65)
+	require.Contains(t, []byte{27, 28}, sigBytes[64])
+
+	pubKey, err := chain.VerifySignature(txID, sigBytes, nil)
+	require.NoError(t, err)
+	require.True(t, chain.PrivateKey.PubKey().IsEqual(pubKey))
+
+	_, err = ParseTronContract(append(rawData, rawData...))
+	require.ErrorContains(t, err, "expected 1 contract, got 2")
+}
+
+func TestTronContracts(t *testing.T) {
+	owner, _ := hex.DecodeString("419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+	to, _ := hex.DecodeString("41a614f803b6fd780986a42c78ec9c7f77e6ded13c")
+
+	// TransferAssetContract { asset_name = 1; owner_address = 2; to_address = 3; amount = 4; }
+	var transfer []byte
+	transfer = appendProtoBytes(transfer, 1, []byte("1002000"))
+	transfer = appendProtoBytes(transfer, 2, owner)
+	transfer = appendProtoBytes(transfer, 3, to)
+	transfer = appendProtoVarint(transfer, 4, 5)
+	contract, err := ParseTronContract(testTronContractRawData(TronTransferAssetContract, "TransferAssetContract", transfer))
+	require.NoError(t, err)
+	require.Equal(t, &TronContract{Type: TronTransferAssetContract, Owner: owner, To: to, Amount: big.NewInt(5)}, contract)
+
+	// AccountPermissionUpdateContract { owner_address = 1; Permission owner = 2; ... }
+	var update []byte
+	update = appendProtoBytes(update, 1, owner)
+	update = appendProtoBytes(update, 2, []byte{0x1a, 0x05, 'o', 'w', 'n', 'e', 'r'})
+	contract, err = ParseTronContract(testTronContractRawData(46, "AccountPermissionUpdateContract", update))
+	require.NoError(t, err)
+	require.Equal(t, &TronContract{Type: 46, Owner: owner}, contract)
+}
+
+// testTronRawData returns the raw_data protobuf of a TRX transfer.
+func testTronRawData(owner []byte, to []byte, amount uint64) []byte {
+	var transfer []byte
+	transfer = appendProtoBytes(transfer, 1, owner)
+	transfer = appendProtoBytes(transfer, 2, to)
+	transfer = appendProtoVarint(transfer, 3, amount)
+	return testTronContractRawData(TronTransferContract, "TransferContract", transfer)
+}
+
+// testTronContractRawData returns the raw_data protobuf of a contract of the type.
+func testTronContractRawData(contractType uint64, typeName string, value []byte) []byte {
+	var parameter, contract, rawData []byte
+	parameter = appendProtoBytes(parameter, 1, []byte("type.googleapis.com/protocol."+typeName))
+	parameter = appendProtoBytes(parameter, 2, value)
+	contract = appendProtoVarint(contract, 1, contractType)
+	contract = appendProtoBytes(contract, 2, parameter)
+
+	rawData = appendProtoBytes(rawData, 1, []byte{0x8f, 0x4e})
+	rawData = appendProtoBytes(rawData, 4, []byte{0x1b, 0x2e, 0x6a, 0x2c, 0x95, 0x1d, 0x24, 0x67})
+	rawData = appendProtoVarint(rawData, 8, 1700000060000)
+	rawData = appendProtoBytes(rawData, 11, contract)
+	return appendProtoVarint(rawData, 14, 1700000000000)
+}
@@ -0,0 +1,353 @@
+package kms
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+const (
+	configStoragePath = "config"
+
+	signatureEncodingBase64 = "base64"
+	signatureEncodingHex    = "hex"
+
+	defaultKeyCacheTTL       = 5 * time.Minute
+	defaultDeletionRetention = 30 * 24 * time.Hour
+)
+
+// kmsConfig includes the mount-wide settings of the wallets and signatures.
+type kmsConfig struct {
+	AllowedChains     []chains.ChainName          `json:"allowed_chains"`
+	DefaultChain      chains.ChainName            `json:"default_chain"`
+	NetworkIDs        map[chains.ChainName]string `json:"network_ids"`
+	SignatureEncoding string                      `json:"signature_encoding"`
+	AllowMsgHash      bool                        `json:"allow_msg_hash"`
+	KeyCacheSize      int                         `json:"key_cache_size"`
+	KeyCacheTTL       time.Duration               `json:"key_cache_ttl"`
+	DeletionRetention time.Duration               `json:"deletion_retention"`
+}
+
+func defaultConfig() *kmsConfig {
+	return &kmsConfig{
+		NetworkIDs:        map[chains.ChainName]string{},
+		SignatureEncoding: signatureEncodingBase64,
+		AllowMsgHash:      true,
+		KeyCacheTTL:       defaultKeyCacheTTL,
+		DeletionRetention: defaultDeletionRetention,
+	}
+}
+
+func pathConfig(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "config",
+			Fields: map[string]*framework.FieldSchema{
+				"allowedChains": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "names of blockchain allowed for wallets, all chains are allowed when empty",
+					Required:    false,
+				},
+				"defaultChain": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain used when a request omits chainName",
+					Required:    false,
+				},
+				"networkIds": {
+					Type:        framework.TypeKVPairs,
+					Description: "network ID per blockchain, such as the ICON nid or the Ethereum chain id",
+					Required:    false,
+				},
+				"signatureEncoding": {
+					Type:        framework.TypeString,
+					Description: "encoding of the returned signatures, base64 or hex",
+					Required:    false,
+					Default:     signatureEncodingBase64,
+				},
+				"allowMsgHash": {
+					Type:        framework.TypeBool,
+					Description: "whether signing a raw msgHash is permitted",
+					Required:    false,
+					Default:     true,
+				},
+				"keyCacheSize": {
+					Type:        framework.TypeInt,
+					Description: "number of decrypted wallet keys cached in memory for signing, 0 disables the cache",
+					Required:    false,
+				},
+				"keyCacheTtl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "duration a decrypted wallet key stays cached",
+					Required:    false,
+					Default:     int(defaultKeyCacheTTL.Seconds()),
+				},
+				"deletionRetention": {
+					Type:        framework.TypeDurationSecond,
+					Description: "duration a deleted wallet is kept and can be restored before it is destroyed",
+					Required:    false,
+					Default:     int(defaultDeletionRetention.Seconds()),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathConfigRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathConfigWrite,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathConfigWrite,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.pathConfigDelete,
+				},
+			},
+			HelpSynopsis:    pathConfigHelpSynopsis,
+			HelpDescription: pathConfigHelpDescription,
+		},
+	}
+}
+
+// getConfig returns the cached configuration, loading it from storage
+// and falling back to the defaults when none is stored.
+func (b *kmsBackend) getConfig(ctx context.Context, s logical.Storage) (*kmsConfig, error) {
+	b.configLock.RLock()
+	config := b.config
+	b.configLock.RUnlock()
+
+	if config != nil {
+		return config, nil
+	}
+
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	if b.config != nil {
+		return b.config, nil
+	}
+
+	entry, err := s.Get(ctx, configStoragePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	config = defaultConfig()
+	if entry != nil {
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, fmt.Errorf("error decode config: %w", err)
+		}
+	}
+
+	if config.KeyCacheSize > 0 {
+		if b.keyCache, err = newKeyCache(config.KeyCacheSize, config.KeyCacheTTL); err != nil {
+			return nil, err
+		}
+	}
+
+	b.config = config
+	return config, nil
+}
+
+// getKeyCache returns the key cache of the loaded configuration,
+// or nil when caching is disabled.
+func (b *kmsBackend) getKeyCache() *keyCache {
+	b.configLock.RLock()
+	defer b.configLock.RUnlock()
+
+	return b.keyCache
+}
+
+// resetConfig drops the cached configuration, and the key cache built from it.
+func (b *kmsBackend) resetConfig() {
+	b.configLock.Lock()
+	defer b.configLock.Unlock()
+
+	b.config = nil
+	b.keyCache.purge()
+	b.keyCache = nil
+}
+
+func (b *kmsBackend) pathConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowedChains":     config.AllowedChains,
+			"defaultChain":      string(config.DefaultChain),
+			"networkIds":        config.NetworkIDs,
+			"signatureEncoding": config.SignatureEncoding,
+			"allowMsgHash":      config.AllowMsgHash,
+			"keyCacheSize":      config.KeyCacheSize,
+			"keyCacheTtl":       int64(config.KeyCacheTTL.Seconds()),
+			"deletionRetention": int64(config.DeletionRetention.Seconds()),
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	current, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the cached configuration, fields not given in the request are kept.
+	config := *current
+
+	if ac, ok := d.GetOk("allowedChains"); ok {
+		config.AllowedChains = nil
+		for _, name := range ac.([]string) {
+			chainName := chains.ChainName(name)
+			if _, err := chains.CoinType(chainName); err != nil {
+				return nil, err
+			}
+			config.AllowedChains = append(config.AllowedChains, chainName)
+		}
+	}
+
+	if dc, ok := d.GetOk("defaultChain"); ok {
+		config.DefaultChain = chains.ChainName(dc.(string))
+	}
+
+	if ni, ok := d.GetOk("networkIds"); ok {
+		config.NetworkIDs = map[chains.ChainName]string{}
+		for name, networkID := range ni.(map[string]string) {
+			if _, err := parseNetworkID(networkID); err != nil {
+				return nil, fmt.Errorf("invalid network id of %v: %w", name, err)
+			}
+			config.NetworkIDs[chains.ChainName(name)] = networkID
+		}
+	}
+
+	if se, ok := d.GetOk("signatureEncoding"); ok {
+		config.SignatureEncoding = se.(string)
+	}
+	if config.SignatureEncoding != signatureEncodingBase64 && config.SignatureEncoding != signatureEncodingHex {
+		return nil, fmt.Errorf("invalid signatureEncoding: %v", config.SignatureEncoding)
+	}
+
+	if am, ok := d.GetOk("allowMsgHash"); ok {
+		config.AllowMsgHash = am.(bool)
+	}
+
+	if ks, ok := d.GetOk("keyCacheSize"); ok {
+		if config.KeyCacheSize = ks.(int); config.KeyCacheSize < 0 {
+			return nil, fmt.Errorf("invalid keyCacheSize: %v", config.KeyCacheSize)
+		}
+	}
+
+	if kt, ok := d.GetOk("keyCacheTtl"); ok {
+		config.KeyCacheTTL = time.Duration(kt.(int)) * time.Second
+	}
+
+	if dr, ok := d.GetOk("deletionRetention"); ok {
+		if config.DeletionRetention = time.Duration(dr.(int)) * time.Second; config.DeletionRetention < 0 {
+			return nil, fmt.Errorf("invalid deletionRetention: %v", dr)
+		}
+	}
+
+	if config.DefaultChain != "" && !config.isChainAllowed(config.DefaultChain) {
+		return nil, fmt.Errorf("defaultChain %v is not allowed", config.DefaultChain)
+	}
+
+	entry, err := logical.StorageEntryJSON(configStoragePath, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.resetConfig()
+
+	return nil, nil
+}
+
+func (b *kmsBackend) pathConfigDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, configStoragePath); err != nil {
+		return nil, fmt.Errorf("error deleting config: %w", err)
+	}
+
+	b.resetConfig()
+
+	return nil, nil
+}
+
+func (c *kmsConfig) isChainAllowed(chainName chains.ChainName) bool {
+	if len(c.AllowedChains) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedChains {
+		if allowed == chainName {
+			return true
+		}
+	}
+	return false
+}
+
+// chainName returns the requested chain, or the default chain when
+// omitted, and checks that it is allowed.
+func (c *kmsConfig) chainName(requested chains.ChainName) (chains.ChainName, error) {
+	if requested == "" {
+		requested = c.DefaultChain
+	}
+	if requested == "" {
+		return "", fmt.Errorf("missing chainName")
+	}
+	if !c.isChainAllowed(requested) {
+		return "", fmt.Errorf("chainName %v is not allowed", requested)
+	}
+	return requested, nil
+}
+
+// networkID returns the configured network ID of the chain.
+func (c *kmsConfig) networkID(chainName chains.ChainName) (uint64, bool) {
+	networkID, ok := c.NetworkIDs[chainName]
+	if !ok {
+		return 0, false
+	}
+	id, err := parseNetworkID(networkID)
+	return id, err == nil
+}
+
+// encodeSignature converts the base64 signature of a chain to the configured encoding.
+func (c *kmsConfig) encodeSignature(signature string) (string, error) {
+	if c.SignatureEncoding != signatureEncodingHex {
+		return signature, nil
+	}
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sigBytes), nil
+}
+
+// parseNetworkID parses a decimal or 0x-prefixed hex network ID.
+func parseNetworkID(s string) (uint64, error) {
+	if strings.HasPrefix(s, "0x") {
+		return strconv.ParseUint(s[2:], 16, 64)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+const (
+	pathConfigHelpSynopsis    = `Configures the mount-wide settings of the KMS backend.`
+	pathConfigHelpDescription = `
+This path lets you configure the chains allowed for wallets, the default chain,
+the network ID per chain, the encoding of returned signatures, whether
+a raw msgHash may be signed, the in-memory cache of decrypted wallet keys,
+and the retention of deleted wallets.
+`
+)
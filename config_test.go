@@ -0,0 +1,127 @@
+package kms
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfig mocks the creation, read and delete of the backend config for kms.
+func TestConfig(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test Config", func(t *testing.T) {
+		resp, err := testConfigRequest(t, b, reqStorage, logical.ReadOperation, nil)
+		require.NoError(t, err)
+		require.Equal(t, signatureEncodingBase64, resp.Data["signatureEncoding"])
+		require.Equal(t, true, resp.Data["allowMsgHash"])
+
+		_, err = testConfigRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"allowedChains": "icon,polkadot",
+		})
+		require.ErrorContains(t, err, "unknown chain name")
+
+		_, err = testConfigRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"allowedChains": "icon",
+			"defaultChain":  "ether",
+		})
+		require.ErrorContains(t, err, "is not allowed")
+
+		_, err = testConfigRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"allowedChains":     "icon,ether",
+			"defaultChain":      "icon",
+			"networkIds":        map[string]interface{}{"icon": "0x1", "ether": "11155111"},
+			"signatureEncoding": signatureEncodingHex,
+			"allowMsgHash":      false,
+		})
+		require.NoError(t, err)
+
+		resp, err = testConfigRequest(t, b, reqStorage, logical.ReadOperation, nil)
+		require.NoError(t, err)
+		require.Equal(t, "icon", resp.Data["defaultChain"])
+		require.Equal(t, false, resp.Data["allowMsgHash"])
+
+		config, err := b.getConfig(context.Background(), reqStorage)
+		require.NoError(t, err)
+
+		networkID, ok := config.networkID("ether")
+		require.True(t, ok)
+		require.Equal(t, uint64(11155111), networkID)
+
+		_, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "aergo",
+		})
+		require.ErrorContains(t, err, "is not allowed")
+
+		resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+		})
+		require.NoError(t, err)
+
+		walletAddress := resp.Data["address"].(string)
+		require.Contains(t, walletAddress, "hx")
+
+		_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"msgHash":  "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8",
+		})
+		require.ErrorContains(t, err, "not permitted")
+
+		resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username":     username,
+			"address":      walletAddress,
+			"txSerialized": "icx_sendTransaction.from." + walletAddress,
+		})
+		require.NoError(t, err)
+
+		signature, err := hex.DecodeString(resp.Data["signature"].(string))
+		require.NoError(t, err)
+		require.Len(t, signature, 65)
+
+		_, err = testConfigRequest(t, b, reqStorage, logical.DeleteOperation, nil)
+		require.NoError(t, err)
+
+		resp, err = testConfigRequest(t, b, reqStorage, logical.ReadOperation, nil)
+		require.NoError(t, err)
+		require.Equal(t, "", resp.Data["defaultChain"])
+	})
+
+	t.Run("Test Config Invalidate", func(t *testing.T) {
+		entry, err := logical.StorageEntryJSON(configStoragePath, &kmsConfig{
+			DefaultChain:      "ether",
+			SignatureEncoding: signatureEncodingBase64,
+		})
+		require.NoError(t, err)
+		require.NoError(t, reqStorage.Put(context.Background(), entry))
+
+		b.invalidate(context.Background(), configStoragePath)
+
+		config, err := b.getConfig(context.Background(), reqStorage)
+		require.NoError(t, err)
+		require.Equal(t, "ether", string(config.DefaultChain))
+	})
+}
+
+func testConfigRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		Path:        configStoragePath,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
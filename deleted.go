@@ -0,0 +1,264 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	deletedStoragePath = "deleted"
+)
+
+func pathWalletDeleted(b *kmsBackend) []*framework.Path {
+	walletFields := map[string]*framework.FieldSchema{
+		"username": {
+			Type:        framework.TypeString,
+			Description: "username of wallet",
+			Required:    true,
+		},
+		"address": {
+			Type:        framework.TypeString,
+			Description: "address of the deleted wallet",
+			Required:    true,
+		},
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "wallet/deleted/(?P<username>[^/]+)/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathWalletDeletedList,
+				},
+			},
+			HelpSynopsis:    pathWalletDeletedHelpSynopsis,
+			HelpDescription: pathWalletDeletedHelpDescription,
+		},
+		{
+			Pattern: "wallet/restore",
+			Fields:  walletFields,
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathWalletRestore,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathWalletRestore,
+				},
+			},
+			HelpSynopsis:    pathWalletDeletedHelpSynopsis,
+			HelpDescription: pathWalletDeletedHelpDescription,
+		},
+		{
+			Pattern: "wallet/destroy",
+			Fields:  walletFields,
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathWalletDestroy,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathWalletDestroy,
+				},
+			},
+			HelpSynopsis:    pathWalletDeletedHelpSynopsis,
+			HelpDescription: pathWalletDeletedHelpDescription,
+		},
+	}
+}
+
+func getDeletedWalletPath(username string, address string) string {
+	return deletedStoragePath + "/" + username + "/" + address
+}
+
+func getDeletedWallet(ctx context.Context, s logical.Storage, username string, address string) (*kmsWallet, error) {
+	entry, err := s.Get(ctx, getDeletedWalletPath(username, address))
+	if err != nil {
+		return nil, fmt.Errorf("error reading deleted wallet: %w", err)
+	}
+
+	if entry == nil {
+		return nil, fmt.Errorf("error not found deleted wallet")
+	}
+
+	wallet := new(kmsWallet)
+	if err := entry.DecodeJSON(wallet); err != nil {
+		return nil, fmt.Errorf("error decode wallet: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// deletedWalletFields returns the username and the address of a restore or destroy request.
+func deletedWalletFields(d *framework.FieldData) (string, string, error) {
+	username, address := d.Get("username").(string), d.Get("address").(string)
+	if username == "" {
+		return "", "", fmt.Errorf("missing username in wallet")
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("missing address in wallet")
+	}
+	return username, address, nil
+}
+
+func (b *kmsBackend) pathWalletDeletedList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username := d.Get("username").(string)
+
+	addresses, err := req.Storage.List(ctx, deletedStoragePath+"/"+username+"/")
+	if err != nil {
+		return nil, fmt.Errorf("error listing deleted wallets: %w", err)
+	}
+
+	keyInfo := make(map[string]interface{}, len(addresses))
+	for _, address := range addresses {
+		// An undecodable wallet is reported with its error, as purge skips it.
+		wallet, err := getDeletedWallet(ctx, req.Storage, username, address)
+		if err != nil {
+			b.Logger().Error("error reading deleted wallet", "username", username, "address", address, "error", err)
+			keyInfo[address] = map[string]interface{}{
+				"address": address,
+				"error":   err.Error(),
+			}
+			continue
+		}
+
+		keyInfo[address] = map[string]interface{}{
+			"address":    wallet.Address,
+			"chain_name": string(wallet.ChainName),
+			"deleted_at": wallet.DeletedAt,
+			"destroy_at": wallet.DestroyAt,
+		}
+	}
+
+	return logical.ListResponseWithInfo(addresses, keyInfo), nil
+}
+
+func (b *kmsBackend) pathWalletRestore(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, address, err := deletedWalletFields(d)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := getDeletedWallet(ctx, req.Storage, username, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry, err := req.Storage.Get(ctx, getWalletPath(username, address)); err != nil {
+		return nil, fmt.Errorf("error reading wallet: %w", err)
+	} else if entry != nil {
+		return nil, fmt.Errorf("wallet already exists: %v", address)
+	}
+	wallet.DeletedAt = nil
+	wallet.DestroyAt = nil
+	if err := putWallet(ctx, req.Storage, username, wallet); err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Delete(ctx, getDeletedWalletPath(username, address)); err != nil {
+		return nil, fmt.Errorf("error deleting deleted wallet: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"address": wallet.Address,
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathWalletDestroy(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, address, err := deletedWalletFields(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getDeletedWallet(ctx, req.Storage, username, address); err != nil {
+		return nil, fmt.Errorf("only a deleted wallet can be destroyed: %w", err)
+	}
+
+	if err := destroyWallet(ctx, req.Storage, username, address); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// destroyWallet permanently removes a deleted wallet, with its policy and limits.
+// The ledger of the wallet is kept.
+func destroyWallet(ctx context.Context, s logical.Storage, username string, address string) error {
+	if err := s.Delete(ctx, getDeletedWalletPath(username, address)); err != nil {
+		return fmt.Errorf("error destroying wallet: %w", err)
+	}
+
+	// A wallet restored or recreated at the same address keeps its restrictions.
+	if entry, err := s.Get(ctx, getWalletPath(username, address)); err != nil || entry != nil {
+		return err
+	}
+
+	if err := s.Delete(ctx, getPolicyPath(username, address)); err != nil {
+		return fmt.Errorf("error deleting policy: %w", err)
+	}
+
+	return deleteLimit(ctx, s, walletLimitScope(username, address))
+}
+
+// purgeDeletedWallets destroys the deleted wallets past their retention window.
+// A wallet failing to be destroyed is logged and left for the next run, so it
+// does not hold back the others.
+func (b *kmsBackend) purgeDeletedWallets(ctx context.Context, s logical.Storage) error {
+	users, err := s.List(ctx, deletedStoragePath+"/")
+	if err != nil {
+		return fmt.Errorf("error listing deleted wallets: %w", err)
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		username := strings.TrimSuffix(user, "/")
+
+		addresses, err := s.List(ctx, deletedStoragePath+"/"+username+"/")
+		if err != nil {
+			b.Logger().Error("error listing deleted wallets", "username", username, "error", err)
+			continue
+		}
+
+		for _, address := range addresses {
+			wallet, err := getDeletedWallet(ctx, s, username, address)
+			if err != nil {
+				b.Logger().Error("error reading deleted wallet", "username", username, "address", address, "error", err)
+				continue
+			}
+			if wallet.DestroyAt != nil && now.Before(*wallet.DestroyAt) {
+				continue
+			}
+
+			if err := destroyWallet(ctx, s, username, address); err != nil {
+				b.Logger().Error("error destroying deleted wallet", "username", username, "address", address, "error", err)
+				continue
+			}
+			b.Logger().Info("destroyed deleted wallet", "username", username, "address", address)
+		}
+	}
+
+	return nil
+}
+
+const (
+	pathWalletDeletedHelpSynopsis    = `Restores or destroys the deleted wallets of a user.`
+	pathWalletDeletedHelpDescription = `
+Deleted wallets are kept for the deletionRetention of the config before they are
+destroyed. List wallet/deleted/<username>/ to get the deleted wallets of a user,
+write wallet/restore to bring a deleted wallet back, or write wallet/destroy to
+permanently remove its private key before the retention window expires.
+A deleted wallet that cannot be read is listed with the error instead of its details.
+`
+)
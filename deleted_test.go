@@ -0,0 +1,149 @@
+package kms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletSoftDelete(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "icon",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+	walletData := map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+	}
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":    username,
+		"address":     walletAddress,
+		"denyMsgHash": true,
+	})
+	require.NoError(t, err)
+
+	t.Run("Test Restore", func(t *testing.T) {
+		require.NoError(t, testWalletDelete(t, b, reqStorage, walletData))
+		require.ErrorContains(t, testWalletDelete(t, b, reqStorage, walletData), "not found")
+
+		_, err := testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username":     username,
+			"address":      walletAddress,
+			"txSerialized": "icx_sendTransaction.nid.0x1",
+		})
+		require.Error(t, err)
+
+		resp, err := testDeletedRequest(t, b, reqStorage, logical.ListOperation, "wallet/deleted/"+username+"/", nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{walletAddress}, resp.Data["keys"])
+
+		_, err = testDeletedRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/restore", walletData)
+		require.NoError(t, err)
+
+		_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username":     username,
+			"address":      walletAddress,
+			"txSerialized": "icx_sendTransaction.nid.0x1",
+		})
+		require.NoError(t, err)
+
+		// The policy is kept while the wallet is deleted.
+		resp, err = testPolicyRequest(t, b, reqStorage, logical.ReadOperation, walletData)
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["denyMsgHash"])
+
+		_, err = testDeletedRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/restore", walletData)
+		require.ErrorContains(t, err, "not found")
+	})
+
+	t.Run("Test Destroy", func(t *testing.T) {
+		_, err := testDeletedRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/destroy", walletData)
+		require.ErrorContains(t, err, "only a deleted wallet can be destroyed")
+
+		require.NoError(t, testWalletDelete(t, b, reqStorage, walletData))
+
+		_, err = testDeletedRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/destroy", walletData)
+		require.NoError(t, err)
+
+		_, err = testDeletedRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/restore", walletData)
+		require.ErrorContains(t, err, "not found")
+
+		resp, err := testPolicyRequest(t, b, reqStorage, logical.ReadOperation, walletData)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+	})
+
+	t.Run("Test Purge", func(t *testing.T) {
+		_, err := testConfigRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"deletionRetention": 3600,
+		})
+		require.NoError(t, err)
+
+		var addresses []string
+		for i := 0; i < 2; i++ {
+			resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+				"username":  username,
+				"chainName": "icon",
+			})
+			require.NoError(t, err)
+			addresses = append(addresses, resp.Data["address"].(string))
+
+			require.NoError(t, testWalletDelete(t, b, reqStorage, map[string]interface{}{
+				"username": username,
+				"address":  addresses[i],
+			}))
+		}
+
+		// Expire the retention of the first wallet.
+		wallet, err := getDeletedWallet(context.Background(), reqStorage, username, addresses[0])
+		require.NoError(t, err)
+		expired := time.Now().Add(-time.Second)
+		wallet.DestroyAt = &expired
+		entry, err := logical.StorageEntryJSON(getDeletedWalletPath(username, addresses[0]), wallet)
+		require.NoError(t, err)
+		require.NoError(t, reqStorage.Put(context.Background(), entry))
+
+		// A corrupt entry does not hold back the other wallets.
+		corrupt := "hx0000000000000000000000000000000000000000"
+		require.NoError(t, reqStorage.Put(context.Background(), &logical.StorageEntry{
+			Key:   getDeletedWalletPath(username, corrupt),
+			Value: []byte("{"),
+		}))
+
+		require.NoError(t, b.periodicFunc(context.Background(), &logical.Request{Storage: reqStorage}))
+
+		resp, err := testDeletedRequest(t, b, reqStorage, logical.ListOperation, "wallet/deleted/"+username+"/", nil)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{corrupt, addresses[1]}, resp.Data["keys"])
+		keyInfo := resp.Data["key_info"].(map[string]interface{})
+		require.Contains(t, keyInfo[corrupt].(map[string]interface{})["error"], "error decode wallet")
+		require.Equal(t, addresses[1], keyInfo[addresses[1]].(map[string]interface{})["address"])
+	})
+}
+
+func testDeletedRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		Path:        path,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
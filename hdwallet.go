@@ -0,0 +1,449 @@
+package kms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	seedStoragePath = "seed"
+	mountSeedName   = "mount"
+
+	hdHardenedOffset = 0x80000000
+	mnemonicBitSize  = 256
+)
+
+// kmsSeed is the BIP-39 seed of a user, or of the mount, from which
+// HD wallets are derived.
+type kmsSeed struct {
+	Seed      string                      `json:"seed"`
+	NextIndex map[chains.ChainName]uint32 `json:"next_index"`
+	CreatedAt time.Time                   `json:"created_at"`
+}
+
+// hdKey is an extended private key as defined in BIP-32.
+type hdKey struct {
+	key       secp256k1.ModNScalar
+	chainCode []byte
+}
+
+func pathSeed(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/seed",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username owning the seed, the mount seed is used when empty",
+					Required:    false,
+				},
+				"mnemonic": {
+					Type:        framework.TypeString,
+					Description: "BIP-39 mnemonic to restore the seed from, a new one is generated when empty",
+					Required:    false,
+				},
+				"passphrase": {
+					Type:        framework.TypeString,
+					Description: "optional BIP-39 passphrase",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathSeedRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSeedCreate,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSeedCreate,
+				},
+			},
+			HelpSynopsis:    pathSeedHelpSynopsis,
+			HelpDescription: pathSeedHelpDescription,
+		},
+	}
+}
+
+func getSeedPath(username string) string {
+	if username == "" {
+		return seedStoragePath + "/" + mountSeedName
+	}
+	return seedStoragePath + "/user/" + username
+}
+
+func getSeed(ctx context.Context, req *logical.Request, seedPath string) (*kmsSeed, error) {
+	entry, err := req.Storage.Get(ctx, seedPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading seed: %w", err)
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	seed := new(kmsSeed)
+	if err := entry.DecodeJSON(&seed); err != nil {
+		return nil, fmt.Errorf("error decode seed: %w", err)
+	}
+
+	return seed, nil
+}
+
+func (b *kmsBackend) pathSeedRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	seed, err := getSeed(ctx, req, getSeedPath(d.Get("username").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	if seed == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"created_at": seed.CreatedAt,
+			"next_index": seed.NextIndex,
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathSeedCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	seedPath := getSeedPath(d.Get("username").(string))
+	if seed, err := getSeed(ctx, req, seedPath); err != nil {
+		return nil, err
+	} else if seed != nil {
+		return nil, fmt.Errorf("seed already exists")
+	}
+
+	mnemonic := strings.TrimSpace(d.Get("mnemonic").(string))
+	generated := mnemonic == ""
+	if generated {
+		entropy, err := bip39.NewEntropy(mnemonicBitSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if mnemonic, err = bip39.NewMnemonic(entropy); err != nil {
+			return nil, err
+		}
+	}
+
+	seedBytes, err := bip39.NewSeedWithErrorChecking(mnemonic, d.Get("passphrase").(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic in seed: %w", err)
+	}
+
+	seed := &kmsSeed{
+		Seed:      hex.EncodeToString(seedBytes),
+		NextIndex: map[chains.ChainName]uint32{},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	entry, err := logical.StorageEntryJSON(seedPath, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	// The mnemonic is never stored, so it is only returned when generated here.
+	if !generated {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"mnemonic": mnemonic,
+		},
+	}, nil
+}
+
+// createHDWallet derives the next wallet of the chain from the seed of
+// the user, falling back to the mount seed.
+func (b *kmsBackend) createHDWallet(ctx context.Context, req *logical.Request, username string, chainName chains.ChainName) (*kmsWallet, error) {
+	coinType, err := chains.CoinType(chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	seedPath := getSeedPath(username)
+	seed, err := getSeed(ctx, req, seedPath)
+	if err == nil && seed == nil {
+		seedPath = getSeedPath("")
+		seed, err = getSeed(ctx, req, seedPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		return nil, fmt.Errorf("missing seed for HD wallet")
+	}
+
+	seedBytes, err := hex.DecodeString(seed.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("error decode seed: %w", err)
+	}
+
+	// Indexes yielding an invalid child key are skipped as BIP-32 requires.
+	keyType := chains.ChainKeyType(chainName)
+	index := seed.NextIndex[chainName]
+	var privateKey chains.PrivateKey
+	for privateKey == nil {
+		if index >= hdHardenedOffset {
+			return nil, fmt.Errorf("HD wallet indexes exhausted for %v", chainName)
+		}
+
+		if privateKey, err = deriveWalletKey(keyType, seedBytes, hdPath(keyType, coinType, index)); err != nil {
+			index++
+		}
+	}
+
+	wallet, err := newWallet(chainName, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.PrivateKey = ""
+	wallet.SeedPath = seedPath
+	wallet.DerivationPath = formatHDPath(hdPath(keyType, coinType, index))
+
+	seed.NextIndex[chainName] = index + 1
+	entry, err := logical.StorageEntryJSON(seedPath, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// walletPrivateKey returns the private key of the wallet, rederiving it
+// from the seed for HD wallets.
+func walletPrivateKey(ctx context.Context, req *logical.Request, wallet *kmsWallet) (chains.PrivateKey, error) {
+	keyType := chains.KeyType(wallet.KeyAlgorithm)
+	if wallet.SeedPath == "" {
+		privKeyBytes, err := hex.DecodeString(wallet.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error decode private key: %w", err)
+		}
+		if keyType == chains.KeySecp256k1 {
+			return secp256k1.PrivKeyFromBytes(privKeyBytes), nil
+		}
+		return chains.ParsePrivateKey(keyType, privKeyBytes)
+	}
+
+	seed, err := getSeed(ctx, req, wallet.SeedPath)
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		return nil, fmt.Errorf("missing seed of HD wallet %v", wallet.Address)
+	}
+
+	seedBytes, err := hex.DecodeString(seed.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("error decode seed: %w", err)
+	}
+
+	path, err := parseHDPath(wallet.DerivationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveWalletKey(keyType, seedBytes, path)
+}
+
+// deriveWalletKey derives the private key of the key type at the path from the seed.
+func deriveWalletKey(keyType chains.KeyType, seed []byte, path []uint32) (chains.PrivateKey, error) {
+	// The keys are returned as chains.PrivateKey only when derived, as a nil
+	// key of either type would make a non-nil interface.
+	if keyType == chains.KeyEd25519 {
+		privateKey, err := deriveEd25519HDKey(seed, path)
+		if err != nil {
+			return nil, err
+		}
+		return privateKey, nil
+	}
+
+	privateKey, err := deriveHDKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return privateKey, nil
+}
+
+// hdPath returns the derivation path of the HD wallet at index. As ed25519
+// keys only derive hardened children, they follow the m/44'/501'/index'/0'
+// path of Solana wallets.
+func hdPath(keyType chains.KeyType, coinType uint32, index uint32) []uint32 {
+	if keyType == chains.KeyEd25519 {
+		return []uint32{
+			44 + hdHardenedOffset,
+			coinType + hdHardenedOffset,
+			index + hdHardenedOffset,
+			hdHardenedOffset,
+		}
+	}
+	return bip44Path(coinType, index)
+}
+
+func bip44Path(coinType uint32, index uint32) []uint32 {
+	return []uint32{
+		44 + hdHardenedOffset,
+		coinType + hdHardenedOffset,
+		hdHardenedOffset,
+		0,
+		index,
+	}
+}
+
+func formatHDPath(path []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range path {
+		if index >= hdHardenedOffset {
+			fmt.Fprintf(&sb, "/%d'", index-hdHardenedOffset)
+		} else {
+			fmt.Fprintf(&sb, "/%d", index)
+		}
+	}
+	return sb.String()
+}
+
+func parseHDPath(s string) ([]uint32, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path: %v", s)
+	}
+
+	path := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var index uint32
+		hardened := strings.HasSuffix(part, "'")
+		if _, err := fmt.Sscanf(strings.TrimSuffix(part, "'"), "%d", &index); err != nil || index >= hdHardenedOffset {
+			return nil, fmt.Errorf("invalid derivation path: %v", s)
+		}
+		if hardened {
+			index += hdHardenedOffset
+		}
+		path = append(path, index)
+	}
+	return path, nil
+}
+
+// deriveHDKey derives the private key at the path from the seed.
+func deriveHDKey(seed []byte, path []uint32) (*secp256k1.PrivateKey, error) {
+	key, err := newHDMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range path {
+		if key, err = key.child(index); err != nil {
+			return nil, err
+		}
+	}
+
+	privateKey := secp256k1.NewPrivateKey(&key.key)
+	key.key.Zero()
+	return privateKey, nil
+}
+
+// deriveEd25519HDKey derives the ed25519 private key at the path from the
+// seed as SLIP-0010 defines, which only derives hardened children.
+func deriveEd25519HDKey(seed []byte, path []uint32) (chains.Ed25519PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	for _, index := range path {
+		if index < hdHardenedOffset {
+			return nil, fmt.Errorf("ed25519 keys only derive hardened children")
+		}
+
+		data := append([]byte{0x00}, sum[:32]...)
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, sum[32:])
+		mac.Write(data)
+		sum = mac.Sum(nil)
+	}
+
+	privateKey, err := chains.ParsePrivateKey(chains.KeyEd25519, sum[:32])
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.(chains.Ed25519PrivateKey), nil
+}
+
+func newHDMasterKey(seed []byte) (*hdKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	return newHDKey(mac.Sum(nil), nil)
+}
+
+// child derives the child key at index (CKDpriv).
+func (k *hdKey) child(index uint32) (*hdKey, error) {
+	var data []byte
+	if index >= hdHardenedOffset {
+		keyBytes := k.key.Bytes()
+		data = append([]byte{0x00}, keyBytes[:]...)
+	} else {
+		data = secp256k1.NewPrivateKey(&k.key).PubKey().SerializeCompressed()
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	return newHDKey(mac.Sum(nil), &k.key)
+}
+
+// newHDKey builds a key from the HMAC-SHA512 output, adding the parent key when given.
+func newHDKey(sum []byte, parent *secp256k1.ModNScalar) (*hdKey, error) {
+	key := new(hdKey)
+	if overflow := key.key.SetByteSlice(sum[:32]); overflow {
+		return nil, fmt.Errorf("invalid HD key")
+	}
+	if parent != nil {
+		key.key.Add(parent)
+	}
+	if key.key.IsZero() {
+		return nil, fmt.Errorf("invalid HD key")
+	}
+	key.chainCode = sum[32:]
+	return key, nil
+}
+
+const (
+	pathSeedHelpSynopsis    = `Manages the HD wallet seed of a user or of the mount.`
+	pathSeedHelpDescription = `
+This path lets you create the BIP-39 seed used to derive HD wallets.
+Leave the username field empty to manage the seed shared by the mount.
+A generated mnemonic is returned once and never stored, so back it up.
+Create wallets with the hd field to derive them along the BIP-44 path of the chain.
+`
+)
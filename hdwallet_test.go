@@ -0,0 +1,187 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDeriveHDKey(t *testing.T) {
+	// Test vector 1 of BIP-32.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	testCases := []struct {
+		path            string
+		expectedPrivKey string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+	}
+
+	for _, tc := range testCases {
+		testName := fmt.Sprintf("Test Derive HD Key %s", tc.path)
+		t.Run(testName, func(t *testing.T) {
+			path, err := parseHDPath(tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.path, formatHDPath(path))
+
+			privateKey, err := deriveHDKey(seed, path)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedPrivKey, hex.EncodeToString(privateKey.Serialize()))
+		})
+	}
+}
+
+func TestDeriveBIP44Address(t *testing.T) {
+	seed := bip39.NewSeed(testMnemonic, "")
+
+	coinType, err := chains.CoinType(chains.ETHER)
+	require.NoError(t, err)
+
+	privateKey, err := deriveHDKey(seed, bip44Path(coinType, 0))
+	require.NoError(t, err)
+
+	wallet, err := newWallet(chains.ETHER, privateKey)
+	require.NoError(t, err)
+	require.Equal(t, "0x9858effd232b4033e47d90003d41ec34ecaeda94", wallet.Address)
+}
+
+func TestDeriveEd25519HDKey(t *testing.T) {
+	// Test vector 1 for ed25519 of SLIP-0010.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	privateKey, err := deriveEd25519HDKey(seed, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", hex.EncodeToString(privateKey.Serialize()))
+
+	privateKey, err = deriveEd25519HDKey(seed, []uint32{hdHardenedOffset})
+	require.NoError(t, err)
+	require.Equal(t, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", hex.EncodeToString(privateKey.Serialize()))
+
+	_, err = deriveEd25519HDKey(seed, []uint32{0})
+	require.ErrorContains(t, err, "only derive hardened children")
+
+	// The first account of Solana wallets, on m/44'/501'/0'/0'.
+	coinType, err := chains.CoinType(chains.SOLANA)
+	require.NoError(t, err)
+
+	path := hdPath(chains.KeyEd25519, coinType, 0)
+	require.Equal(t, "m/44'/501'/0'/0'", formatHDPath(path))
+
+	walletKey, err := deriveWalletKey(chains.KeyEd25519, bip39.NewSeed(testMnemonic, ""), path)
+	require.NoError(t, err)
+
+	wallet, err := newWallet(chains.SOLANA, walletKey)
+	require.NoError(t, err)
+	require.Equal(t, "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", wallet.Address)
+	require.Equal(t, "ed25519", wallet.KeyAlgorithm)
+}
+
+// TestHDWallet mocks the seed creation, HD wallet derivation and signing for kms.
+func TestHDWallet(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test HD Wallet", func(t *testing.T) {
+		_, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+			"hd":        true,
+		})
+		require.ErrorContains(t, err, "missing seed")
+
+		_, err = testSeedCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"mnemonic": testMnemonic,
+		})
+		require.NoError(t, err)
+
+		_, err = testSeedCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+		})
+		require.ErrorContains(t, err, "seed already exists")
+
+		resp, err := testSeedCreate(t, b, reqStorage, map[string]interface{}{})
+		require.NoError(t, err)
+		require.True(t, bip39.IsMnemonicValid(resp.Data["mnemonic"].(string)))
+
+		resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+			"hd":        true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0x9858effd232b4033e47d90003d41ec34ecaeda94", resp.Data["address"])
+
+		resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+			"hd":        true,
+		})
+		require.NoError(t, err)
+
+		address := resp.Data["address"].(string)
+		wallet, err := getWallet(context.Background(), &logical.Request{Storage: reqStorage}, getWalletPath(username, address))
+		require.NoError(t, err)
+		require.Empty(t, wallet.PrivateKey)
+		require.Equal(t, "m/44'/60'/0'/0/1", wallet.DerivationPath)
+
+		resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  address,
+			"msgHash":  "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8",
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Data["signature"])
+
+		resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "solana",
+			"hd":        true,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", resp.Data["address"])
+
+		pubKey, err := chains.DecodeSolanaAddress(resp.Data["address"].(string))
+		require.NoError(t, err)
+		resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  resp.Data["address"],
+			"tx": map[string]interface{}{
+				"message": b64.StdEncoding.EncodeToString(testSolanaTransfer(pubKey, bytes.Repeat([]byte{0x02}, 32), 5000)),
+			},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.Data["signature"])
+	})
+}
+
+func testSeedCreate(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.CreateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/seed",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
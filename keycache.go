@@ -0,0 +1,114 @@
+package kms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type keyCacheEntry struct {
+	wallet     kmsWallet
+	privateKey chains.PrivateKey
+	expiresAt  time.Time
+}
+
+// keyCache is a bounded LRU cache of decoded wallets and their parsed
+// private keys, keyed by wallet storage path. Evicted keys are zeroed.
+// A nil keyCache caches nothing.
+type keyCache struct {
+	lock sync.Mutex
+	lru  *simplelru.LRU
+	ttl  time.Duration
+}
+
+func newKeyCache(size int, ttl time.Duration) (*keyCache, error) {
+	lru, err := simplelru.NewLRU(size, func(_ interface{}, value interface{}) {
+		value.(*keyCacheEntry).privateKey.Zero()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyCache{
+		lru: lru,
+		ttl: ttl,
+	}, nil
+}
+
+// get returns copies of the cached wallet and private key, so that
+// eviction never zeroes a key in use.
+func (c *keyCache) get(walletPath string) (*kmsWallet, chains.PrivateKey, bool) {
+	if c == nil {
+		return nil, nil, false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	value, ok := c.lru.Get(walletPath)
+	if !ok {
+		return nil, nil, false
+	}
+
+	entry := value.(*keyCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.lru.Remove(walletPath)
+		return nil, nil, false
+	}
+
+	wallet := entry.wallet
+	return &wallet, copyPrivateKey(entry.privateKey), true
+}
+
+func (c *keyCache) add(walletPath string, wallet *kmsWallet, privateKey chains.PrivateKey) {
+	if c == nil {
+		return
+	}
+
+	entry := &keyCacheEntry{
+		wallet:     *wallet,
+		privateKey: copyPrivateKey(privateKey),
+		expiresAt:  time.Now().Add(c.ttl),
+	}
+	entry.wallet.PrivateKey = ""
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Adding an existing key replaces it without eviction, so remove it first.
+	c.lru.Remove(walletPath)
+	c.lru.Add(walletPath, entry)
+}
+
+func (c *keyCache) remove(walletPath string) {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.Remove(walletPath)
+}
+
+func (c *keyCache) purge() {
+	if c == nil {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.Purge()
+}
+
+// copyPrivateKey returns a copy of the private key, which zeroing the key does not clear.
+func copyPrivateKey(privateKey chains.PrivateKey) chains.PrivateKey {
+	if key, ok := privateKey.(chains.Ed25519PrivateKey); ok {
+		return append(chains.Ed25519PrivateKey(nil), key...)
+	}
+	return secp256k1.NewPrivateKey(&privateKey.(*secp256k1.PrivateKey).Key)
+}
@@ -0,0 +1,136 @@
+package kms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyCache(t *testing.T) {
+	t.Run("Test Eviction", func(t *testing.T) {
+		cache, err := newKeyCache(1, time.Minute)
+		require.NoError(t, err)
+
+		key1, err := secp256k1.GeneratePrivateKey()
+		require.NoError(t, err)
+		key2, err := secp256k1.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		cache.add("wallet/user/1", &kmsWallet{Address: "1", PrivateKey: "secret"}, key1)
+
+		wallet, privateKey, ok := cache.get("wallet/user/1")
+		require.True(t, ok)
+		require.Equal(t, "1", wallet.Address)
+		require.Empty(t, wallet.PrivateKey)
+		require.Equal(t, key1.Serialize(), privateKey.Serialize())
+
+		value, _ := cache.lru.Peek("wallet/user/1")
+		cached := value.(*keyCacheEntry).privateKey
+
+		cache.add("wallet/user/2", &kmsWallet{Address: "2"}, key2)
+
+		_, _, ok = cache.get("wallet/user/1")
+		require.False(t, ok)
+		require.True(t, cached.(*secp256k1.PrivateKey).Key.IsZero())
+		require.Equal(t, key1.Serialize(), privateKey.Serialize())
+	})
+
+	t.Run("Test Ed25519 Eviction", func(t *testing.T) {
+		cache, err := newKeyCache(1, time.Minute)
+		require.NoError(t, err)
+
+		key, err := chains.GeneratePrivateKey(chains.KeyEd25519)
+		require.NoError(t, err)
+		seed := key.Serialize()
+
+		cache.add("wallet/user/1", &kmsWallet{Address: "1"}, key)
+		_, privateKey, ok := cache.get("wallet/user/1")
+		require.True(t, ok)
+
+		cache.remove("wallet/user/1")
+		require.Equal(t, seed, key.Serialize())
+		require.Equal(t, seed, privateKey.Serialize())
+	})
+
+	t.Run("Test Expiry", func(t *testing.T) {
+		cache, err := newKeyCache(1, -time.Second)
+		require.NoError(t, err)
+
+		key, err := secp256k1.GeneratePrivateKey()
+		require.NoError(t, err)
+
+		cache.add("wallet/user/1", &kmsWallet{Address: "1"}, key)
+
+		_, _, ok := cache.get("wallet/user/1")
+		require.False(t, ok)
+		require.Equal(t, 0, cache.lru.Len())
+	})
+
+	t.Run("Test Disabled", func(t *testing.T) {
+		var cache *keyCache
+
+		cache.add("wallet/user/1", &kmsWallet{}, secp256k1.PrivKeyFromBytes([]byte{1}))
+		_, _, ok := cache.get("wallet/user/1")
+		require.False(t, ok)
+	})
+}
+
+func TestKeyCacheSign(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	_, err := testConfigRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"keyCacheSize": 4,
+		"keyCacheTtl":  60,
+	})
+	require.NoError(t, err)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "icon",
+	})
+	require.NoError(t, err)
+	address := resp.Data["address"].(string)
+
+	reqData := map[string]interface{}{
+		"username": username,
+		"address":  address,
+		"msgHash":  "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+	}
+	resp, err = testSignCreate(t, b, reqStorage, reqData)
+	require.NoError(t, err)
+	signature := resp.Data["signature"]
+
+	walletPath := getWalletPath(username, address)
+	_, _, ok := b.getKeyCache().get(walletPath)
+	require.True(t, ok)
+
+	resp, err = testSignCreate(t, b, reqStorage, reqData)
+	require.NoError(t, err)
+	require.Equal(t, signature, resp.Data["signature"])
+
+	b.InvalidateKey(context.Background(), walletPath)
+	_, _, ok = b.getKeyCache().get(walletPath)
+	require.False(t, ok)
+
+	_, err = testSignCreate(t, b, reqStorage, reqData)
+	require.NoError(t, err)
+
+	err = testWalletDelete(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  address,
+	})
+	require.NoError(t, err)
+	_, _, ok = b.getKeyCache().get(walletPath)
+	require.False(t, ok)
+
+	_, err = testSignCreate(t, b, reqStorage, reqData)
+	require.Error(t, err)
+
+	b.InvalidateKey(context.Background(), configStoragePath)
+	require.Nil(t, b.getKeyCache())
+}
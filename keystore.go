@@ -0,0 +1,164 @@
+package kms
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Bounds of the KDF parameters of imported keystores, which are untrusted:
+// well above the parameters wallets write, and below those exhausting the
+// memory or the CPU of the server deriving the key.
+const (
+	keystoreDKLen      = 32
+	keystoreMaxScryptN = 1 << 20
+	keystoreMaxScryptR = 8
+	keystoreMaxScryptP = 16
+	keystoreMaxPbkdf2C = 10000000
+)
+
+// keystoreV3 is the Web3 Secret Storage layout shared by
+// Ethereum V3 keystores and ICON keystores (coinType "icx").
+type keystoreV3 struct {
+	Address  string           `json:"address"`
+	Crypto   keystoreV3Crypto `json:"crypto"`
+	Version  int              `json:"version"`
+	CoinType string           `json:"coinType,omitempty"`
+}
+
+type keystoreV3Crypto struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams keystoreV3CipherParams `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+type keystoreV3CipherParams struct {
+	IV string `json:"iv"`
+}
+
+// decryptKeystore returns the private key and the address stored in the keystore JSON.
+func decryptKeystore(keystoreJSON []byte, password string) ([]byte, string, error) {
+	var ks keystoreV3
+	if err := json.Unmarshal(keystoreJSON, &ks); err != nil {
+		return nil, "", fmt.Errorf("invalid keystore: %w", err)
+	}
+
+	if ks.Version != 3 {
+		return nil, "", fmt.Errorf("unsupported keystore version: %v", ks.Version)
+	}
+	if ks.Crypto.Cipher != "aes-128-ctr" {
+		return nil, "", fmt.Errorf("unsupported keystore cipher: %v", ks.Crypto.Cipher)
+	}
+
+	derivedKey, err := keystoreDerivedKey(ks.Crypto.KDF, ks.Crypto.KDFParams, password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cipherText, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid keystore ciphertext: %w", err)
+	}
+
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid keystore mac: %w", err)
+	}
+
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(derivedKey[16:32])
+	hasher.Write(cipherText)
+	if !bytes.Equal(hasher.Sum(nil), mac) {
+		return nil, "", fmt.Errorf("keystore mac mismatch, wrong password")
+	}
+
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid keystore iv: %w", err)
+	}
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, "", err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, "", fmt.Errorf("invalid keystore iv length: %v", len(iv))
+	}
+
+	privKeyBytes := make([]byte, len(cipherText))
+	cipher.NewCTR(block, iv).XORKeyStream(privKeyBytes, cipherText)
+
+	return privKeyBytes, ks.Address, nil
+}
+
+func keystoreDerivedKey(kdf string, params map[string]interface{}, password string) ([]byte, error) {
+	salt, err := hex.DecodeString(keystoreParamString(params, "salt"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore salt: %w", err)
+	}
+
+	dkLen := keystoreParamInt(params, "dklen")
+	if dkLen != keystoreDKLen {
+		return nil, fmt.Errorf("invalid keystore dklen: %v", dkLen)
+	}
+
+	switch kdf {
+	case "scrypt":
+		n := keystoreParamInt(params, "n")
+		r := keystoreParamInt(params, "r")
+		p := keystoreParamInt(params, "p")
+		if n <= 1 || n > keystoreMaxScryptN || r <= 0 || r > keystoreMaxScryptR || p <= 0 || p > keystoreMaxScryptP {
+			return nil, fmt.Errorf("unsupported keystore scrypt params: n=%v, r=%v, p=%v", n, r, p)
+		}
+		return scrypt.Key([]byte(password), salt, n, r, p, dkLen)
+	case "pbkdf2":
+		if prf := keystoreParamString(params, "prf"); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported keystore prf: %v", prf)
+		}
+		c := keystoreParamInt(params, "c")
+		if c <= 0 || c > keystoreMaxPbkdf2C {
+			return nil, fmt.Errorf("unsupported keystore pbkdf2 iterations: %v", c)
+		}
+		return pbkdf2.Key([]byte(password), salt, c, dkLen, sha256.New), nil
+	}
+	return nil, fmt.Errorf("unsupported keystore kdf: %v", kdf)
+}
+
+func keystoreParamString(params map[string]interface{}, key string) string {
+	if v, ok := params[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// keystoreParamInt returns the integer parameter, or -1 when it is missing
+// or not an integer of the int32 range.
+func keystoreParamInt(params map[string]interface{}, key string) int {
+	if v, ok := params[key].(float64); ok && v == math.Trunc(v) && v >= 0 && v <= math.MaxInt32 {
+		return int(v)
+	}
+	return -1
+}
+
+// keystoreAddressMatches compares a keystore address with a derived one,
+// ignoring the "0x"/"hx" prefix and case.
+func keystoreAddressMatches(keystoreAddress string, address string) bool {
+	trim := func(s string) string {
+		s = strings.ToLower(s)
+		return strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "hx")
+	}
+	return keystoreAddress == "" || trim(keystoreAddress) == trim(address)
+}
@@ -0,0 +1,55 @@
+package kms
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vector from the Web3 Secret Storage Definition.
+const (
+	testKeystoreJSON = `{
+		"crypto": {
+			"cipher": "aes-128-ctr",
+			"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+			"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+			"kdf": "pbkdf2",
+			"kdfparams": {
+				"c": 262144,
+				"dklen": 32,
+				"prf": "hmac-sha256",
+				"salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"
+			},
+			"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+		},
+		"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		"version": 3
+	}`
+	testKeystorePassword   = "testpassword"
+	testKeystorePrivateKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+)
+
+func TestDecryptKeystore(t *testing.T) {
+	privKeyBytes, _, err := decryptKeystore([]byte(testKeystoreJSON), testKeystorePassword)
+	require.NoError(t, err)
+	require.Equal(t, testKeystorePrivateKey, hex.EncodeToString(privKeyBytes))
+
+	_, _, err = decryptKeystore([]byte(testKeystoreJSON), "wrong-password")
+	require.ErrorContains(t, err, "mac mismatch")
+
+	scrypt := strings.NewReplacer(`"kdf": "pbkdf2"`, `"kdf": "scrypt"`, `"prf": "hmac-sha256",`, "")
+	for _, tc := range []struct {
+		keystoreJSON string
+		expected     string
+	}{
+		{strings.Replace(testKeystoreJSON, `"c": 262144`, `"c": 1e12`, 1), "unsupported keystore pbkdf2 iterations"},
+		{strings.Replace(testKeystoreJSON, `"dklen": 32`, `"dklen": 64`, 1), "invalid keystore dklen"},
+		{scrypt.Replace(strings.Replace(testKeystoreJSON, `"c": 262144`, `"n": 1073741824, "r": 8, "p": 1`, 1)), "unsupported keystore scrypt params"},
+		{scrypt.Replace(strings.Replace(testKeystoreJSON, `"c": 262144`, `"n": 262144, "r": 1024, "p": 1`, 1)), "unsupported keystore scrypt params"},
+	} {
+		_, _, err = decryptKeystore([]byte(tc.keystoreJSON), testKeystorePassword)
+		require.ErrorContains(t, err, tc.expected)
+	}
+}
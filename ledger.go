@@ -0,0 +1,425 @@
+package kms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+const (
+	ledgerStoragePath = "ledger"
+
+	ledgerKindTx           = "tx"
+	ledgerKindTxSerialized = "tx_serialized"
+	ledgerKindMsgHash      = "msg_hash"
+	ledgerKindMessage      = "message"
+	ledgerKindTypedData    = "typed_data"
+)
+
+// ledgerEntry records a signature produced with a wallet. The entries of
+// a wallet form a chain, each including the hash of the previous one.
+// The hashes are unkeyed, so the chain detects lost or corrupted entries,
+// not an attacker with write access to the storage, who can rehash it.
+type ledgerEntry struct {
+	Sequence   uint64           `json:"sequence"`
+	WalletPath string           `json:"wallet_path"`
+	ChainName  chains.ChainName `json:"chain_name"`
+	Kind       string           `json:"kind"`
+	Digest     string           `json:"digest"`
+	Signature  string           `json:"signature"`
+	Tx         *ledgerTxSummary `json:"tx,omitempty"`
+	EntityID   string           `json:"entity_id"`
+	Timestamp  time.Time        `json:"timestamp"`
+	PrevHash   string           `json:"prev_hash"`
+	Hash       string           `json:"hash"`
+}
+
+type ledgerTxSummary struct {
+	To      string `json:"to,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Method  string `json:"method,omitempty"`
+	ChainID string `json:"chain_id,omitempty"`
+}
+
+// ledgerHead is the last entry of the ledger of a wallet.
+type ledgerHead struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+func pathLedger(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/ledger/(?P<username>[^/]+)/(?P<address>[^/]+)/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+				"after": {
+					Type:        framework.TypeString,
+					Description: "optional entry sequence to list after",
+					Required:    false,
+				},
+				"limit": {
+					Type:        framework.TypeInt,
+					Description: "optional number of entries to return",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathLedgerList,
+				},
+			},
+			HelpSynopsis:    pathLedgerHelpSynopsis,
+			HelpDescription: pathLedgerHelpDescription,
+		},
+		{
+			Pattern: "wallet/ledger/(?P<username>[^/]+)/(?P<address>[^/]+)/verify$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathLedgerVerify,
+				},
+			},
+			HelpSynopsis:    pathLedgerHelpSynopsis,
+			HelpDescription: pathLedgerHelpDescription,
+		},
+		{
+			Pattern: "wallet/ledger/(?P<username>[^/]+)/(?P<address>[^/]+)/(?P<sequence>\\d+)$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+				"sequence": {
+					Type:        framework.TypeString,
+					Description: "sequence of the ledger entry",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathLedgerRead,
+				},
+			},
+			HelpSynopsis:    pathLedgerHelpSynopsis,
+			HelpDescription: pathLedgerHelpDescription,
+		},
+	}
+}
+
+func getLedgerPath(username string, address string) string {
+	return ledgerStoragePath + "/" + username + "/" + address
+}
+
+func getLedgerEntryPath(ledgerPath string, sequence uint64) string {
+	return ledgerPath + "/entry/" + strconv.FormatUint(sequence, 10)
+}
+
+func getLedgerHead(ctx context.Context, s logical.Storage, ledgerPath string) (*ledgerHead, error) {
+	entry, err := s.Get(ctx, ledgerPath+"/head")
+	if err != nil {
+		return nil, fmt.Errorf("error reading ledger: %w", err)
+	}
+
+	head := new(ledgerHead)
+	if entry == nil {
+		return head, nil
+	}
+
+	if err := entry.DecodeJSON(head); err != nil {
+		return nil, fmt.Errorf("error decode ledger: %w", err)
+	}
+	return head, nil
+}
+
+func getLedgerEntry(ctx context.Context, s logical.Storage, ledgerPath string, sequence uint64) (*ledgerEntry, error) {
+	entry, err := s.Get(ctx, getLedgerEntryPath(ledgerPath, sequence))
+	if err != nil {
+		return nil, fmt.Errorf("error reading ledger entry: %w", err)
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	ledger := new(ledgerEntry)
+	if err := entry.DecodeJSON(ledger); err != nil {
+		return nil, fmt.Errorf("error decode ledger entry: %w", err)
+	}
+	return ledger, nil
+}
+
+// hash returns the hex SHA-256 of the JSON encoding of the entry without its hash.
+func (e *ledgerEntry) hash() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+
+	encoded, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256(encoded)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// newLedgerTxSummary converts the summary of a decoded transaction, or returns nil.
+func newLedgerTxSummary(summary *txSummary) *ledgerTxSummary {
+	if summary == nil {
+		return nil
+	}
+
+	ledgerTx := &ledgerTxSummary{
+		To:      summary.To,
+		Method:  summary.Method,
+		ChainID: summary.ChainID,
+	}
+	if summary.Value != nil {
+		ledgerTx.Value = summary.Value.String()
+	}
+	return ledgerTx
+}
+
+// appendLedger chains the entry to the ledger of its wallet and stores it.
+func (b *kmsBackend) appendLedger(ctx context.Context, s logical.Storage, username string, address string, entry *ledgerEntry) error {
+	b.ledgerLock.Lock()
+	defer b.ledgerLock.Unlock()
+
+	ledgerPath := getLedgerPath(username, address)
+	head, err := getLedgerHead(ctx, s, ledgerPath)
+	if err != nil {
+		return err
+	}
+
+	entry.Sequence = head.Sequence + 1
+	entry.WalletPath = getWalletPath(username, address)
+	entry.Timestamp = time.Now().UTC()
+	entry.PrevHash = head.Hash
+	if entry.Hash, err = entry.hash(); err != nil {
+		return err
+	}
+
+	storageEntry, err := logical.StorageEntryJSON(getLedgerEntryPath(ledgerPath, entry.Sequence), entry)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, storageEntry); err != nil {
+		return fmt.Errorf("error writing ledger entry: %w", err)
+	}
+
+	storageEntry, err = logical.StorageEntryJSON(ledgerPath+"/head", &ledgerHead{
+		Sequence: entry.Sequence,
+		Hash:     entry.Hash,
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.Put(ctx, storageEntry); err != nil {
+		return fmt.Errorf("error writing ledger: %w", err)
+	}
+
+	return nil
+}
+
+func (b *kmsBackend) pathLedgerList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ledgerPath := getLedgerPath(d.Get("username").(string), d.Get("address").(string))
+
+	keys, err := req.Storage.List(ctx, ledgerPath+"/entry/")
+	if err != nil {
+		return nil, err
+	}
+
+	sequences := make([]uint64, 0, len(keys))
+	for _, key := range keys {
+		if sequence, err := strconv.ParseUint(key, 10, 64); err == nil {
+			sequences = append(sequences, sequence)
+		}
+	}
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+
+	var after uint64
+	if a := d.Get("after").(string); a != "" {
+		if after, err = strconv.ParseUint(a, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid after in ledger: %v", a)
+		}
+	}
+	limit := d.Get("limit").(int)
+
+	keys = keys[:0]
+	keyInfo := map[string]interface{}{}
+	for _, sequence := range sequences {
+		if sequence <= after {
+			continue
+		}
+		if limit > 0 && len(keys) >= limit {
+			break
+		}
+
+		entry, err := getLedgerEntry(ctx, req.Storage, ledgerPath, sequence)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		key := strconv.FormatUint(sequence, 10)
+		keys = append(keys, key)
+		keyInfo[key] = map[string]interface{}{
+			"chain_name": entry.ChainName,
+			"kind":       entry.Kind,
+			"digest":     entry.Digest,
+			"timestamp":  entry.Timestamp,
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *kmsBackend) pathLedgerRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	sequence, err := strconv.ParseUint(d.Get("sequence").(string), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sequence in ledger: %w", err)
+	}
+
+	entry, err := getLedgerEntry(ctx, req.Storage, getLedgerPath(d.Get("username").(string), d.Get("address").(string)), sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	data := map[string]interface{}{
+		"sequence":    entry.Sequence,
+		"wallet_path": entry.WalletPath,
+		"chain_name":  entry.ChainName,
+		"kind":        entry.Kind,
+		"digest":      entry.Digest,
+		"signature":   entry.Signature,
+		"entity_id":   entry.EntityID,
+		"timestamp":   entry.Timestamp,
+		"prev_hash":   entry.PrevHash,
+		"hash":        entry.Hash,
+	}
+	if entry.Tx != nil {
+		data["tx"] = entry.Tx
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+func (b *kmsBackend) pathLedgerVerify(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ledgerPath := getLedgerPath(d.Get("username").(string), d.Get("address").(string))
+
+	head, err := getLedgerHead(ctx, req.Storage, ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"valid":     true,
+		"entries":   head.Sequence,
+		"head_hash": head.Hash,
+	}
+	broken := func(sequence uint64, reason string) (*logical.Response, error) {
+		data["valid"] = false
+		data["broken_at"] = sequence
+		data["error"] = reason
+		return &logical.Response{Data: data}, nil
+	}
+
+	prevHash := ""
+	for sequence := uint64(1); sequence <= head.Sequence; sequence++ {
+		entry, err := getLedgerEntry(ctx, req.Storage, ledgerPath, sequence)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return broken(sequence, "missing entry")
+		}
+		if entry.Sequence != sequence {
+			return broken(sequence, "sequence mismatch")
+		}
+		if entry.PrevHash != prevHash {
+			return broken(sequence, "prev_hash mismatch")
+		}
+
+		hash, err := entry.hash()
+		if err != nil {
+			return nil, err
+		}
+		if hash != entry.Hash {
+			return broken(sequence, "hash mismatch")
+		}
+		prevHash = hash
+	}
+
+	if prevHash != head.Hash {
+		return broken(head.Sequence, "head hash mismatch")
+	}
+
+	// An entry past the head means the head was rolled back.
+	if entry, err := getLedgerEntry(ctx, req.Storage, ledgerPath, head.Sequence+1); err != nil {
+		return nil, err
+	} else if entry != nil {
+		return broken(head.Sequence+1, "entry past head")
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+const (
+	pathLedgerHelpSynopsis    = `Reads and verifies the signing ledger of a wallet.`
+	pathLedgerHelpDescription = `
+Every signature produced with a wallet is appended to its ledger, with the chain,
+the signed digest, the decoded tx summary, the requesting entity and the time.
+Each entry includes the hash of the previous one, so that a lost or corrupted
+entry breaks the chain of hashes. The hashes are plain SHA-256, not keyed, so
+they do not prove the ledger against anyone able to write the Vault storage,
+who can rewrite the entries and their hashes together.
+List wallet/ledger/<username>/<address>/ to get the entries, read
+wallet/ledger/<username>/<address>/<sequence> to get an entry, and read
+wallet/ledger/<username>/<address>/verify to check the chain of hashes is intact.
+`
+)
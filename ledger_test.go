@@ -0,0 +1,117 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+	ledgerPath := "wallet/ledger/" + username + "/" + walletAddress + "/"
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"chainId":  "1",
+			"nonce":    "0x0",
+			"gasPrice": "0x3b9aca00",
+			"gas":      "0x5208",
+			"to":       "0x3535353535353535353535353535353535353535",
+			"value":    "1000",
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"msgHash":  "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+	})
+	require.NoError(t, err)
+
+	_, err = testSignMessage(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"message":  "hello",
+	})
+	require.NoError(t, err)
+
+	t.Run("Test List", func(t *testing.T) {
+		resp, err := testLedgerRequest(t, b, reqStorage, logical.ListOperation, ledgerPath, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2", "3"}, resp.Data["keys"])
+
+		resp, err = testLedgerRequest(t, b, reqStorage, logical.ListOperation, ledgerPath, map[string]interface{}{
+			"after": "1",
+			"limit": 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"2"}, resp.Data["keys"])
+	})
+
+	t.Run("Test Read", func(t *testing.T) {
+		resp, err := testLedgerRequest(t, b, reqStorage, logical.ReadOperation, ledgerPath+"1", nil)
+		require.NoError(t, err)
+		require.Equal(t, ledgerKindTx, resp.Data["kind"])
+		require.Equal(t, "", resp.Data["prev_hash"])
+		require.Equal(t, "1000", resp.Data["tx"].(*ledgerTxSummary).Value)
+		firstHash := resp.Data["hash"]
+
+		resp, err = testLedgerRequest(t, b, reqStorage, logical.ReadOperation, ledgerPath+"2", nil)
+		require.NoError(t, err)
+		require.Equal(t, ledgerKindMsgHash, resp.Data["kind"])
+		require.Equal(t, firstHash, resp.Data["prev_hash"])
+		require.Equal(t, "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1", resp.Data["digest"])
+	})
+
+	t.Run("Test Verify", func(t *testing.T) {
+		resp, err := testLedgerRequest(t, b, reqStorage, logical.ReadOperation, ledgerPath+"verify", nil)
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["valid"])
+		require.Equal(t, uint64(3), resp.Data["entries"])
+
+		ledger := getLedgerPath(username, walletAddress)
+		entry, err := getLedgerEntry(context.Background(), reqStorage, ledger, 2)
+		require.NoError(t, err)
+		entry.Digest = "00"
+		storageEntry, err := logical.StorageEntryJSON(getLedgerEntryPath(ledger, 2), entry)
+		require.NoError(t, err)
+		require.NoError(t, reqStorage.Put(context.Background(), storageEntry))
+
+		resp, err = testLedgerRequest(t, b, reqStorage, logical.ReadOperation, ledgerPath+"verify", nil)
+		require.NoError(t, err)
+		require.Equal(t, false, resp.Data["valid"])
+		require.Equal(t, uint64(2), resp.Data["broken_at"])
+		require.Equal(t, "hash mismatch", resp.Data["error"])
+	})
+}
+
+func testLedgerRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		Path:        path,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
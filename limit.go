@@ -0,0 +1,569 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+const (
+	limitStoragePath = "limit"
+	usageStoragePath = "usage"
+
+	defaultSignatureWindow = time.Hour
+	defaultValueWindow     = 24 * time.Hour
+
+	// usageBuckets is the number of buckets a window is counted in.
+	usageBuckets = 60
+)
+
+// kmsLimit caps the signatures of a wallet, or of all the wallets of a
+// user on a chain, within rolling windows.
+type kmsLimit struct {
+	MaxSignatures   int           `json:"max_signatures"`
+	SignatureWindow time.Duration `json:"signature_window"`
+	MaxValue        string        `json:"max_value,omitempty"`
+	ValueWindow     time.Duration `json:"value_window"`
+}
+
+// kmsUsage records the signatures counted against a limit, in fixed time
+// buckets of each window whose limit is set, so that its size does not
+// grow with the number of signatures.
+type kmsUsage struct {
+	Signatures []usageBucket `json:"signatures,omitempty"`
+	Values     []usageBucket `json:"values,omitempty"`
+}
+
+// usageBucket counts the signatures, or sums their value, from its start
+// for a 60th of the window.
+type usageBucket struct {
+	Start int64  `json:"start"`
+	Count int    `json:"count,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// limitScope locates the limit and the usage of a wallet, or of a user on a chain.
+type limitScope struct {
+	name      string
+	limitPath string
+	usagePath string
+}
+
+func pathLimit(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/limit",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet, the limit of the user on chainName is managed when empty",
+					Required:    false,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain of the user limit, defaults to the default chain",
+					Required:    false,
+				},
+				"maxSignatures": {
+					Type:        framework.TypeInt,
+					Description: "maximum number of signatures within signatureWindow, 0 for no limit",
+					Required:    false,
+				},
+				"signatureWindow": {
+					Type:        framework.TypeDurationSecond,
+					Description: "rolling window of maxSignatures",
+					Required:    false,
+					Default:     int(defaultSignatureWindow.Seconds()),
+				},
+				"maxValue": {
+					Type:        framework.TypeString,
+					Description: "maximum total value within valueWindow in the smallest unit of the chain, as a decimal or 0x-prefixed hex string",
+					Required:    false,
+				},
+				"valueWindow": {
+					Type:        framework.TypeDurationSecond,
+					Description: "rolling window of maxValue",
+					Required:    false,
+					Default:     int(defaultValueWindow.Seconds()),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathLimitRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathLimitWrite,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathLimitWrite,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.pathLimitDelete,
+				},
+			},
+			HelpSynopsis:    pathLimitHelpSynopsis,
+			HelpDescription: pathLimitHelpDescription,
+		},
+		{
+			Pattern: "wallet/usage",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet, only the usage of the user is returned when empty",
+					Required:    false,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain of the user usage, defaults to the chain of the wallet",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathUsageRead,
+				},
+			},
+			HelpSynopsis:    pathUsageHelpSynopsis,
+			HelpDescription: pathUsageHelpDescription,
+		},
+	}
+}
+
+func walletLimitScope(username string, address string) limitScope {
+	return limitScope{
+		name:      "wallet",
+		limitPath: limitStoragePath + "/wallet/" + username + "/" + address,
+		usagePath: usageStoragePath + "/wallet/" + username + "/" + address,
+	}
+}
+
+func userLimitScope(username string, chainName chains.ChainName) limitScope {
+	return limitScope{
+		name:      "user",
+		limitPath: limitStoragePath + "/user/" + username + "/" + string(chainName),
+		usagePath: usageStoragePath + "/user/" + username + "/" + string(chainName),
+	}
+}
+
+// signLimitScopes returns the scopes a signature of the wallet counts against.
+func signLimitScopes(username string, address string, chainName chains.ChainName) []limitScope {
+	return []limitScope{
+		walletLimitScope(username, address),
+		userLimitScope(username, chainName),
+	}
+}
+
+// limitScopeOf returns the scope of a wallet/limit or wallet/usage request.
+func (b *kmsBackend) limitScopeOf(ctx context.Context, req *logical.Request, d *framework.FieldData) (limitScope, error) {
+	un, ok := d.GetOk("username")
+	if !ok || un.(string) == "" {
+		return limitScope{}, fmt.Errorf("missing username in limit")
+	}
+	username := un.(string)
+
+	if address := d.Get("address").(string); address != "" {
+		return walletLimitScope(username, address), nil
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return limitScope{}, err
+	}
+
+	chainName, err := config.chainName(chains.ChainName(d.Get("chainName").(string)))
+	if err != nil {
+		return limitScope{}, err
+	}
+	return userLimitScope(username, chainName), nil
+}
+
+func getLimit(ctx context.Context, s logical.Storage, limitPath string) (*kmsLimit, error) {
+	entry, err := s.Get(ctx, limitPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading limit: %w", err)
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	limit := new(kmsLimit)
+	if err := entry.DecodeJSON(limit); err != nil {
+		return nil, fmt.Errorf("error decode limit: %w", err)
+	}
+
+	return limit, nil
+}
+
+func getUsage(ctx context.Context, s logical.Storage, usagePath string) (*kmsUsage, error) {
+	entry, err := s.Get(ctx, usagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading usage: %w", err)
+	}
+
+	usage := new(kmsUsage)
+	if entry == nil {
+		return usage, nil
+	}
+
+	if err := entry.DecodeJSON(usage); err != nil {
+		return nil, fmt.Errorf("error decode usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+func (b *kmsBackend) pathLimitRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	scope, err := b.limitScopeOf(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := getLimit(ctx, req.Storage, scope.limitPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"maxSignatures":   limit.MaxSignatures,
+			"signatureWindow": int64(limit.SignatureWindow.Seconds()),
+			"maxValue":        limit.MaxValue,
+			"valueWindow":     int64(limit.ValueWindow.Seconds()),
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathLimitWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	scope, err := b.limitScopeOf(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if address := d.Get("address").(string); address != "" {
+		if _, err := getWallet(ctx, req, getWalletPath(d.Get("username").(string), address)); err != nil {
+			return nil, err
+		}
+	}
+
+	limit, err := getLimit(ctx, req.Storage, scope.limitPath)
+	if err != nil {
+		return nil, err
+	}
+	if limit == nil {
+		limit = &kmsLimit{
+			SignatureWindow: defaultSignatureWindow,
+			ValueWindow:     defaultValueWindow,
+		}
+	}
+
+	// Fields not given in the request are kept.
+	if ms, ok := d.GetOk("maxSignatures"); ok {
+		if limit.MaxSignatures = ms.(int); limit.MaxSignatures < 0 {
+			return nil, fmt.Errorf("invalid maxSignatures: %v", limit.MaxSignatures)
+		}
+	}
+
+	if sw, ok := d.GetOk("signatureWindow"); ok {
+		limit.SignatureWindow = time.Duration(sw.(int)) * time.Second
+	}
+
+	if mv, ok := d.GetOk("maxValue"); ok {
+		limit.MaxValue = mv.(string)
+		if limit.MaxValue != "" {
+			if _, err := txBigInt(map[string]interface{}{"maxValue": limit.MaxValue}, "maxValue"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if vw, ok := d.GetOk("valueWindow"); ok {
+		limit.ValueWindow = time.Duration(vw.(int)) * time.Second
+	}
+
+	if limit.SignatureWindow <= 0 || limit.ValueWindow <= 0 {
+		return nil, fmt.Errorf("invalid window in limit")
+	}
+
+	entry, err := logical.StorageEntryJSON(scope.limitPath, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *kmsBackend) pathLimitDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	scope, err := b.limitScopeOf(ctx, req, d)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteLimit(ctx, req.Storage, scope); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func deleteLimit(ctx context.Context, s logical.Storage, scope limitScope) error {
+	if err := s.Delete(ctx, scope.limitPath); err != nil {
+		return fmt.Errorf("error deleting limit: %w", err)
+	}
+	if err := s.Delete(ctx, scope.usagePath); err != nil {
+		return fmt.Errorf("error deleting usage: %w", err)
+	}
+	return nil
+}
+
+func (b *kmsBackend) pathUsageRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	un, ok := d.GetOk("username")
+	if !ok || un.(string) == "" {
+		return nil, fmt.Errorf("missing username in usage")
+	}
+	username := un.(string)
+
+	chainName := chains.ChainName(d.Get("chainName").(string))
+
+	var scopes []limitScope
+	if address := d.Get("address").(string); address != "" {
+		wallet, err := getWallet(ctx, req, getWalletPath(username, address))
+		if err != nil {
+			return nil, err
+		}
+		if chainName, err = wallet.resolveChainName(chainName); err != nil {
+			return nil, err
+		}
+		scopes = signLimitScopes(username, address, chainName)
+	} else {
+		scope, err := b.limitScopeOf(ctx, req, d)
+		if err != nil {
+			return nil, err
+		}
+		scopes = []limitScope{scope}
+	}
+
+	now := time.Now().UTC()
+	data := map[string]interface{}{}
+	for _, scope := range scopes {
+		limit, err := getLimit(ctx, req.Storage, scope.limitPath)
+		if err != nil {
+			return nil, err
+		}
+		if limit == nil {
+			continue
+		}
+
+		usage, err := getUsage(ctx, req.Storage, scope.usagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		signatures, value := usage.within(limit, now)
+		scopeData := map[string]interface{}{
+			"signatures":       signatures,
+			"signature_window": int64(limit.SignatureWindow.Seconds()),
+			"value":            value.String(),
+			"value_window":     int64(limit.ValueWindow.Seconds()),
+		}
+		if limit.MaxSignatures > 0 {
+			scopeData["max_signatures"] = limit.MaxSignatures
+			scopeData["remaining_signatures"] = max(limit.MaxSignatures-signatures, 0)
+		}
+		if maxValue := limit.maxValue(); maxValue != nil {
+			remaining := new(big.Int).Sub(maxValue, value)
+			if remaining.Sign() < 0 {
+				remaining.SetInt64(0)
+			}
+			scopeData["max_value"] = maxValue.String()
+			scopeData["remaining_value"] = remaining.String()
+		}
+		data[scope.name] = scopeData
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// maxValue returns the parsed maximum total value, or nil for no limit.
+func (l *kmsLimit) maxValue() *big.Int {
+	if l.MaxValue == "" {
+		return nil
+	}
+	maxValue, _ := txBigInt(map[string]interface{}{"maxValue": l.MaxValue}, "maxValue")
+	return maxValue
+}
+
+// usageBucketSize returns the duration of the buckets of the window.
+func usageBucketSize(window time.Duration) time.Duration {
+	return max(window/usageBuckets, time.Second)
+}
+
+// inWindow reports whether any part of the bucket is in the window ending
+// at now. A bucket counts until its end leaves the window, so that the
+// limits are never exceeded, at the cost of up to a bucket of delay.
+func (bucket usageBucket) inWindow(window time.Duration, now time.Time) bool {
+	return now.Sub(time.Unix(bucket.Start, 0)) < window+usageBucketSize(window)
+}
+
+// within returns the signatures and the total value counted in the
+// rolling windows of the limit ending at now.
+func (u *kmsUsage) within(limit *kmsLimit, now time.Time) (int, *big.Int) {
+	signatures, value := 0, new(big.Int)
+	for _, bucket := range u.Signatures {
+		if bucket.inWindow(limit.SignatureWindow, now) {
+			signatures += bucket.Count
+		}
+	}
+	for _, bucket := range u.Values {
+		if v, ok := new(big.Int).SetString(bucket.Value, 10); ok && bucket.inWindow(limit.ValueWindow, now) {
+			value.Add(value, v)
+		}
+	}
+	return signatures, value
+}
+
+// prune drops the buckets out of their window, and those of the windows
+// whose limit is not set.
+func (u *kmsUsage) prune(limit *kmsLimit, now time.Time) {
+	pruneBuckets := func(buckets []usageBucket, window time.Duration, set bool) []usageBucket {
+		var kept []usageBucket
+		for _, bucket := range buckets {
+			if set && bucket.inWindow(window, now) {
+				kept = append(kept, bucket)
+			}
+		}
+		return kept
+	}
+	u.Signatures = pruneBuckets(u.Signatures, limit.SignatureWindow, limit.MaxSignatures > 0)
+	u.Values = pruneBuckets(u.Values, limit.ValueWindow, limit.maxValue() != nil)
+}
+
+// add counts a signature of the value in the windows whose limit is set.
+func (u *kmsUsage) add(limit *kmsLimit, now time.Time, value *big.Int) {
+	if limit.MaxSignatures > 0 {
+		bucket := lastUsageBucket(&u.Signatures, limit.SignatureWindow, now)
+		bucket.Count++
+	}
+	if limit.maxValue() != nil && value != nil {
+		bucket := lastUsageBucket(&u.Values, limit.ValueWindow, now)
+		total, _ := new(big.Int).SetString(bucket.Value, 10)
+		if total == nil {
+			total = new(big.Int)
+		}
+		bucket.Value = total.Add(total, value).String()
+	}
+}
+
+// lastUsageBucket returns the bucket of the window containing now, appending it when missing.
+func lastUsageBucket(buckets *[]usageBucket, window time.Duration, now time.Time) *usageBucket {
+	start := now.Truncate(usageBucketSize(window)).Unix()
+	if n := len(*buckets); n == 0 || (*buckets)[n-1].Start != start {
+		*buckets = append(*buckets, usageBucket{Start: start})
+	}
+	return &(*buckets)[len(*buckets)-1]
+}
+
+// reserveUsage checks that one more signature of the given value fits the
+// limits of the scopes, and records it. A nil value stands for a signature
+// whose value cannot be decoded, which is refused under a value limit.
+func (b *kmsBackend) reserveUsage(ctx context.Context, s logical.Storage, scopes []limitScope, value *big.Int) error {
+	b.usageLock.Lock()
+	defer b.usageLock.Unlock()
+
+	now := time.Now().UTC()
+	limits := map[string]*kmsLimit{}
+	usages := map[string]*kmsUsage{}
+	for _, scope := range scopes {
+		limit, err := getLimit(ctx, s, scope.limitPath)
+		if err != nil {
+			return err
+		}
+		if limit == nil || (limit.MaxSignatures == 0 && limit.maxValue() == nil) {
+			continue
+		}
+
+		usage, err := getUsage(ctx, s, scope.usagePath)
+		if err != nil {
+			return err
+		}
+		usage.prune(limit, now)
+
+		signatures, total := usage.within(limit, now)
+		if limit.MaxSignatures > 0 && signatures >= limit.MaxSignatures {
+			return fmt.Errorf("%v limit exceeded: %v signatures per %v", scope.name, limit.MaxSignatures, limit.SignatureWindow)
+		}
+		if maxValue := limit.maxValue(); maxValue != nil {
+			if value == nil {
+				return fmt.Errorf("%v limit exceeded: the value cannot be counted, sign a structured tx of known value instead", scope.name)
+			}
+			if total.Add(total, value).Cmp(maxValue) > 0 {
+				return fmt.Errorf("%v limit exceeded: total value %v per %v", scope.name, maxValue, limit.ValueWindow)
+			}
+		}
+		limits[scope.usagePath] = limit
+		usages[scope.usagePath] = usage
+	}
+
+	for usagePath, usage := range usages {
+		usage.add(limits[usagePath], now, value)
+
+		entry, err := logical.StorageEntryJSON(usagePath, usage)
+		if err != nil {
+			return err
+		}
+		if err := s.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+const (
+	pathLimitHelpSynopsis    = `Manages the velocity limits of a wallet, or of a user on a chain.`
+	pathLimitHelpDescription = `
+This path lets you cap the signatures of wallet/sign within rolling windows:
+the number of signatures within signatureWindow, and the total native value
+of the signed transactions within valueWindow. The signatures of typed data
+and messages are counted with no value. Windows are counted in buckets of a
+60th of their length, so a signature leaves its window up to a bucket late.
+Provide the address to limit a wallet, or omit it to limit all the wallets
+of the user on chainName. Both the wallet and the user limits are enforced.
+Under a value limit, txSerialized and msgHash are refused as their value cannot be counted.
+`
+
+	pathUsageHelpSynopsis    = `Reads the current usage of the velocity limits.`
+	pathUsageHelpDescription = `
+This path returns, for the wallet and for its user on the chain, the signatures
+and the total value counted in the current windows, and the remaining budget.
+`
+)
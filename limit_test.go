@@ -0,0 +1,168 @@
+package kms
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimit(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+
+	resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+	otherAddress := resp.Data["address"].(string)
+
+	sign := func(address string, value string) error {
+		_, err := testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  address,
+			"tx": map[string]interface{}{
+				"chainId":  "1",
+				"nonce":    "0x0",
+				"gasPrice": "0x3b9aca00",
+				"gas":      "0x5208",
+				"to":       "0x3535353535353535353535353535353535353535",
+				"value":    value,
+			},
+		})
+		return err
+	}
+
+	t.Run("Test Wallet Limit", func(t *testing.T) {
+		_, err := testLimitRequest(t, b, reqStorage, "wallet/limit", logical.CreateOperation, map[string]interface{}{
+			"username":      username,
+			"address":       walletAddress,
+			"maxSignatures": 2,
+			"maxValue":      "100",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, sign(walletAddress, "60"))
+
+		_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"msgHash":  "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+		})
+		require.ErrorContains(t, err, "cannot be counted")
+
+		require.ErrorContains(t, sign(walletAddress, "50"), "wallet limit exceeded: total value 100")
+		require.NoError(t, sign(walletAddress, "40"))
+		require.ErrorContains(t, sign(walletAddress, "0"), "wallet limit exceeded: 2 signatures")
+
+		resp, err := testLimitRequest(t, b, reqStorage, "wallet/usage", logical.ReadOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+
+		usage := resp.Data["wallet"].(map[string]interface{})
+		require.Equal(t, 2, usage["signatures"])
+		require.Equal(t, 0, usage["remaining_signatures"])
+		require.Equal(t, "100", usage["value"])
+		require.Equal(t, "0", usage["remaining_value"])
+		require.NotContains(t, resp.Data, "user")
+
+		require.NoError(t, sign(otherAddress, "1000"))
+	})
+
+	t.Run("Test User Limit", func(t *testing.T) {
+		_, err := testLimitRequest(t, b, reqStorage, "wallet/limit", logical.CreateOperation, map[string]interface{}{
+			"username":      username,
+			"chainName":     "ether",
+			"maxSignatures": 1,
+		})
+		require.NoError(t, err)
+
+		resp, err := testLimitRequest(t, b, reqStorage, "wallet/limit", logical.ReadOperation, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, resp.Data["maxSignatures"])
+		require.Equal(t, int64(defaultSignatureWindow.Seconds()), resp.Data["signatureWindow"])
+
+		require.NoError(t, sign(otherAddress, "1000"))
+		require.ErrorContains(t, sign(otherAddress, "1000"), "user limit exceeded")
+
+		resp, err = testLimitRequest(t, b, reqStorage, "wallet/usage", logical.ReadOperation, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, resp.Data["user"].(map[string]interface{})["signatures"])
+
+		_, err = testLimitRequest(t, b, reqStorage, "wallet/limit", logical.DeleteOperation, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, sign(otherAddress, "1000"))
+	})
+}
+
+func TestUsageBuckets(t *testing.T) {
+	limit := &kmsLimit{MaxSignatures: 100000, SignatureWindow: time.Hour, ValueWindow: 24 * time.Hour}
+	start := time.Unix(1700000040, 0)
+
+	usage := new(kmsUsage)
+	for i := 0; i < 10000; i++ {
+		usage.add(limit, start.Add(time.Duration(i)*10*time.Millisecond), big.NewInt(5))
+	}
+	require.Len(t, usage.Signatures, 2)
+	require.Empty(t, usage.Values, "no value is recorded without a value limit")
+
+	signatures, _ := usage.within(limit, start.Add(time.Hour))
+	require.Equal(t, 10000, signatures)
+
+	// The first minute leaves the window once its end does.
+	signatures, _ = usage.within(limit, start.Add(time.Hour+time.Minute))
+	require.Equal(t, 4000, signatures)
+
+	usage.prune(limit, start.Add(time.Hour+2*time.Minute))
+	require.Empty(t, usage.Signatures)
+
+	limit = &kmsLimit{MaxValue: "1000", SignatureWindow: time.Hour, ValueWindow: 24 * time.Hour}
+	usage.add(limit, start, big.NewInt(5))
+	usage.add(limit, start.Add(time.Second), big.NewInt(7))
+	require.Empty(t, usage.Signatures, "no signature is counted without a signature limit")
+	require.Len(t, usage.Values, 1)
+
+	_, value := usage.within(limit, start.Add(time.Hour))
+	require.Equal(t, big.NewInt(12), value)
+}
+
+func testLimitRequest(t *testing.T, b logical.Backend, s logical.Storage, path string, op logical.Operation, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		Path:        path,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -0,0 +1,445 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	policyStoragePath = "policy"
+
+	defaultApprovalTTL = 24 * time.Hour
+)
+
+// kmsPolicy restricts what a wallet may sign. A policy is stored per
+// wallet, or per user as the default of the wallets without their own.
+type kmsPolicy struct {
+	AllowedTo       []string `json:"allowed_to,omitempty"`
+	MaxValue        string   `json:"max_value,omitempty"`
+	AllowedMethods  []string `json:"allowed_methods,omitempty"`
+	AllowedChainIDs []string `json:"allowed_chain_ids,omitempty"`
+	DenyMsgHash     bool     `json:"deny_msg_hash"`
+
+	// Approvers are the Vault entity IDs allowed to approve sign requests,
+	// of which RequiredApprovals must approve before a signature is produced.
+	Approvers         []string      `json:"approvers,omitempty"`
+	RequiredApprovals int           `json:"required_approvals,omitempty"`
+	ApprovalTTL       time.Duration `json:"approval_ttl,omitempty"`
+
+	// AllowRetired lets a wallet retired by a rotation keep signing.
+	AllowRetired bool `json:"allow_retired,omitempty"`
+}
+
+// txSummary holds the fields of a decoded transaction that policies
+// are evaluated against. Partial is set when the transaction has effects
+// To and Value are not read from, such as a message other than a transfer,
+// so that allowedTo and maxValue deny it rather than checking only part of it.
+// Data is set when the transaction carries a payload, so that allowedMethods
+// denies it when no Method can be read from the payload.
+type txSummary struct {
+	To      string
+	Value   *big.Int
+	Method  string
+	ChainID string
+	Partial bool
+	Data    bool
+}
+
+func pathPolicy(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/policy",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet, the default policy of the user is managed when empty",
+					Required:    false,
+				},
+				"allowedTo": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "destination addresses allowed in signed transactions, any destination is allowed when empty",
+					Required:    false,
+				},
+				"maxValue": {
+					Type:        framework.TypeString,
+					Description: "maximum value per transaction in the smallest unit of the chain, as a decimal or 0x-prefixed hex string",
+					Required:    false,
+				},
+				"allowedMethods": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "contract methods allowed in signed transactions, such as an ICON method name or dataType, an Ethereum 4-byte selector, or deploy for a contract creation",
+					Required:    false,
+				},
+				"allowedChainIds": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "chain ids allowed in signed transactions, such as the ICON nid, the Ethereum chain id or the Aergo chainIdHash",
+					Required:    false,
+				},
+				"denyMsgHash": {
+					Type:        framework.TypeBool,
+					Description: "whether signing a raw msgHash is forbidden",
+					Required:    false,
+				},
+				"approvers": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Vault entity IDs allowed to approve the sign requests of the wallet",
+					Required:    false,
+				},
+				"requiredApprovals": {
+					Type:        framework.TypeInt,
+					Description: "number of approvals required before signing, 0 allows signing without approval",
+					Required:    false,
+				},
+				"allowRetired": {
+					Type:        framework.TypeBool,
+					Description: "whether a wallet retired by a rotation may still sign",
+					Required:    false,
+				},
+				"approvalTtl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "duration a sign request waits for approvals before expiring",
+					Required:    false,
+					Default:     int(defaultApprovalTTL.Seconds()),
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathPolicyRead,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathPolicyWrite,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathPolicyWrite,
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.pathPolicyDelete,
+				},
+			},
+			HelpSynopsis:    pathPolicyHelpSynopsis,
+			HelpDescription: pathPolicyHelpDescription,
+		},
+	}
+}
+
+func getPolicyPath(username string, address string) string {
+	if address == "" {
+		return policyStoragePath + "/user/" + username
+	}
+	return policyStoragePath + "/wallet/" + username + "/" + address
+}
+
+func getPolicy(ctx context.Context, s logical.Storage, policyPath string) (*kmsPolicy, error) {
+	entry, err := s.Get(ctx, policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy: %w", err)
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	policy := new(kmsPolicy)
+	if err := entry.DecodeJSON(policy); err != nil {
+		return nil, fmt.Errorf("error decode policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// walletPolicy returns the policy of the wallet, falling back to the
+// default policy of the user, or nil when neither exists.
+func walletPolicy(ctx context.Context, s logical.Storage, username string, address string) (*kmsPolicy, error) {
+	policy, err := getPolicy(ctx, s, getPolicyPath(username, address))
+	if err != nil || policy != nil {
+		return policy, err
+	}
+	return getPolicy(ctx, s, getPolicyPath(username, ""))
+}
+
+func policyUsername(d *framework.FieldData) (string, error) {
+	un, ok := d.GetOk("username")
+	if !ok {
+		return "", fmt.Errorf("missing username in policy")
+	}
+	if un.(string) == "" {
+		return "", fmt.Errorf("empty username in policy")
+	}
+	return un.(string), nil
+}
+
+func (b *kmsBackend) pathPolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, err := policyUsername(d)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := getPolicy(ctx, req.Storage, getPolicyPath(username, d.Get("address").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowedTo":         policy.AllowedTo,
+			"maxValue":          policy.MaxValue,
+			"allowedMethods":    policy.AllowedMethods,
+			"allowedChainIds":   policy.AllowedChainIDs,
+			"denyMsgHash":       policy.DenyMsgHash,
+			"approvers":         policy.Approvers,
+			"requiredApprovals": policy.RequiredApprovals,
+			"approvalTtl":       int64(policy.approvalTTL().Seconds()),
+			"allowRetired":      policy.AllowRetired,
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, err := policyUsername(d)
+	if err != nil {
+		return nil, err
+	}
+
+	address := d.Get("address").(string)
+	if address != "" {
+		if _, err := getWallet(ctx, req, getWalletPath(username, address)); err != nil {
+			return nil, err
+		}
+	}
+
+	policyPath := getPolicyPath(username, address)
+	policy, err := getPolicy(ctx, req.Storage, policyPath)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = new(kmsPolicy)
+	}
+
+	// Fields not given in the request are kept.
+	if at, ok := d.GetOk("allowedTo"); ok {
+		policy.AllowedTo = at.([]string)
+	}
+
+	if mv, ok := d.GetOk("maxValue"); ok {
+		policy.MaxValue = mv.(string)
+		if policy.MaxValue != "" {
+			if _, err := txBigInt(map[string]interface{}{"maxValue": policy.MaxValue}, "maxValue"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if am, ok := d.GetOk("allowedMethods"); ok {
+		policy.AllowedMethods = am.([]string)
+	}
+
+	if ac, ok := d.GetOk("allowedChainIds"); ok {
+		policy.AllowedChainIDs = nil
+		for _, chainID := range ac.([]string) {
+			policy.AllowedChainIDs = append(policy.AllowedChainIDs, normalizeChainID(chainID))
+		}
+	}
+
+	if dm, ok := d.GetOk("denyMsgHash"); ok {
+		policy.DenyMsgHash = dm.(bool)
+	}
+
+	if ap, ok := d.GetOk("approvers"); ok {
+		policy.Approvers = ap.([]string)
+	}
+
+	if ra, ok := d.GetOk("requiredApprovals"); ok {
+		policy.RequiredApprovals = ra.(int)
+	}
+	if policy.RequiredApprovals < 0 || policy.RequiredApprovals > len(policy.Approvers) {
+		return nil, fmt.Errorf("invalid requiredApprovals: %v of %v approvers", policy.RequiredApprovals, len(policy.Approvers))
+	}
+
+	if ar, ok := d.GetOk("allowRetired"); ok {
+		policy.AllowRetired = ar.(bool)
+	}
+
+	if at, ok := d.GetOk("approvalTtl"); ok {
+		if policy.ApprovalTTL = time.Duration(at.(int)) * time.Second; policy.ApprovalTTL <= 0 {
+			return nil, fmt.Errorf("invalid approvalTtl: %v", at)
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(policyPath, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *kmsBackend) pathPolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	username, err := policyUsername(d)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Delete(ctx, getPolicyPath(username, d.Get("address").(string))); err != nil {
+		return nil, fmt.Errorf("error deleting policy: %w", err)
+	}
+
+	return nil, nil
+}
+
+// normalizeChainID formats a numeric chain id in decimal, so that the
+// ICON nid 0x1 matches 1. Other chain ids are kept as given.
+func normalizeChainID(chainID string) string {
+	if n, err := parseNetworkID(chainID); err == nil {
+		return strconv.FormatUint(n, 10)
+	}
+	return chainID
+}
+
+// checkTx returns an error when the transaction violates the policy.
+// A nil summary stands for a transaction that cannot be decoded.
+func (p *kmsPolicy) checkTx(summary *txSummary) error {
+	if p == nil {
+		return nil
+	}
+
+	if summary == nil {
+		if p.restrictsTx() {
+			return fmt.Errorf("policy denied: txSerialized cannot be checked against the policy, sign a structured tx instead")
+		}
+		return nil
+	}
+
+	if summary.Partial && (len(p.AllowedTo) > 0 || p.MaxValue != "") {
+		return fmt.Errorf("policy denied: the destination and value of every part of the tx cannot be checked against the policy")
+	}
+
+	if len(p.AllowedTo) > 0 && !containsFold(p.AllowedTo, summary.To) {
+		return fmt.Errorf("policy denied: destination %v is not allowed", summary.To)
+	}
+
+	if p.MaxValue != "" {
+		maxValue, err := txBigInt(map[string]interface{}{"maxValue": p.MaxValue}, "maxValue")
+		if err != nil {
+			return err
+		}
+		if summary.Value == nil {
+			return fmt.Errorf("policy denied: the value of the tx cannot be checked against maxValue")
+		}
+		if summary.Value.Cmp(maxValue) > 0 {
+			return fmt.Errorf("policy denied: value %v exceeds maximum %v", summary.Value, maxValue)
+		}
+	}
+
+	if len(p.AllowedMethods) > 0 {
+		if summary.Method == "" && summary.Data {
+			return fmt.Errorf("policy denied: the method of the tx data cannot be checked against allowedMethods")
+		}
+		if summary.Method != "" && !containsFold(p.AllowedMethods, summary.Method) {
+			return fmt.Errorf("policy denied: method %v is not allowed", summary.Method)
+		}
+	}
+
+	if len(p.AllowedChainIDs) > 0 && !containsFold(p.AllowedChainIDs, normalizeChainID(summary.ChainID)) {
+		return fmt.Errorf("policy denied: chain id %v is not allowed", summary.ChainID)
+	}
+
+	return nil
+}
+
+// restrictsTx reports whether the policy restricts the content of the transactions.
+func (p *kmsPolicy) restrictsTx() bool {
+	return len(p.AllowedTo) > 0 || p.MaxValue != "" || len(p.AllowedMethods) > 0 || len(p.AllowedChainIDs) > 0
+}
+
+// checkMessage returns an error when the policy forbids signing a message
+// of the kind, typed data or a personal message. Messages are treated as an
+// opaque msgHash, since their effect cannot be decoded: typed data such as
+// permits authorizes token transfers.
+func (p *kmsPolicy) checkMessage(kind string) error {
+	if p == nil {
+		return nil
+	}
+	if p.DenyMsgHash {
+		return fmt.Errorf("policy denied: %v signing is not permitted", kind)
+	}
+	if p.restrictsTx() {
+		return fmt.Errorf("policy denied: %v cannot be checked against the policy", kind)
+	}
+	return nil
+}
+
+// requiresApproval reports whether signing requires approvals.
+func (p *kmsPolicy) requiresApproval() bool {
+	return p != nil && p.RequiredApprovals > 0
+}
+
+func (p *kmsPolicy) approvalTTL() time.Duration {
+	if p.ApprovalTTL <= 0 {
+		return defaultApprovalTTL
+	}
+	return p.ApprovalTTL
+}
+
+// checkWallet returns an error when the policy forbids signing with the wallet.
+func (p *kmsPolicy) checkWallet(wallet *kmsWallet) error {
+	if wallet.Status == walletStatusRetired && (p == nil || !p.AllowRetired) {
+		return fmt.Errorf("wallet is retired, its successor is %v", wallet.Successor)
+	}
+	return nil
+}
+
+// checkMsgHash returns an error when the policy forbids signing a raw msgHash,
+// which may be the hash of any tx and so is refused by the tx restrictions.
+func (p *kmsPolicy) checkMsgHash() error {
+	return p.checkMessage("msgHash")
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	pathPolicyHelpSynopsis    = `Manages the signing policy of a wallet, or the default of a user.`
+	pathPolicyHelpDescription = `
+This path lets you restrict what wallet/sign may sign with a wallet:
+the allowed destination addresses, the maximum value per transaction,
+the allowed contract methods and chain ids, and whether a raw msgHash
+may be signed. Omit the address to manage the default policy of the user,
+which applies to the wallets without their own policy.
+Policies are checked against the decoded tx before any signature is produced,
+so a restricted wallet refuses to sign an opaque txSerialized, msgHash, typed
+data or personal message, and denyMsgHash also forbids typed data and personal
+messages.
+allowedTo and maxValue also deny a tx with parts whose destination and value are
+not decoded, such as a Cosmos message other than a bank send or a Solana
+instruction other than a System transfer.
+Set approvers and requiredApprovals to require M-of-N approvals of the
+sign requests of wallet/sign/request instead of signing directly.
+`
+)
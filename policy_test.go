@@ -0,0 +1,195 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+
+	newTx := func() map[string]interface{} {
+		return map[string]interface{}{
+			"chainId":  "11155111",
+			"nonce":    "0x0",
+			"gasPrice": "0x3b9aca00",
+			"gas":      "0x5208",
+			"to":       "0x3535353535353535353535353535353535353535",
+			"value":    "1000",
+		}
+	}
+	sign := func(d map[string]interface{}) error {
+		d["username"] = username
+		d["address"] = walletAddress
+		_, err := testSignCreate(t, b, reqStorage, d)
+		return err
+	}
+
+	t.Run("Test User Policy", func(t *testing.T) {
+		_, err := testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":    username,
+			"denyMsgHash": true,
+		})
+		require.NoError(t, err)
+
+		err = sign(map[string]interface{}{
+			"msgHash": "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+		})
+		require.ErrorContains(t, err, "msgHash signing is not permitted")
+
+		require.NoError(t, sign(map[string]interface{}{"tx": newTx()}))
+	})
+
+	t.Run("Test Wallet Policy", func(t *testing.T) {
+		_, err := testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":        username,
+			"address":         walletAddress,
+			"allowedTo":       "0x3535353535353535353535353535353535353535",
+			"maxValue":        "0x3e8",
+			"allowedMethods":  "0xa9059cbb",
+			"allowedChainIds": "0xaa36a7",
+		})
+		require.NoError(t, err)
+
+		resp, err := testPolicyRequest(t, b, reqStorage, logical.ReadOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"11155111"}, resp.Data["allowedChainIds"])
+		require.Equal(t, false, resp.Data["denyMsgHash"])
+
+		// The wallet policy replaces the default policy of the user.
+		require.ErrorContains(t, sign(map[string]interface{}{
+			"msgHash": "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+		}), "msgHash cannot be checked against the policy")
+		require.NoError(t, sign(map[string]interface{}{"tx": newTx()}))
+
+		tx := newTx()
+		tx["to"] = "0x1111111111111111111111111111111111111111"
+		require.ErrorContains(t, sign(map[string]interface{}{"tx": tx}), "destination")
+
+		tx = newTx()
+		tx["value"] = "1001"
+		require.ErrorContains(t, sign(map[string]interface{}{"tx": tx}), "exceeds maximum")
+
+		tx = newTx()
+		tx["data"] = "0x095ea7b3"
+		require.ErrorContains(t, sign(map[string]interface{}{"tx": tx}), "method 0x095ea7b3 is not allowed")
+
+		tx["data"] = "0xa9059cbb"
+		require.NoError(t, sign(map[string]interface{}{"tx": tx}))
+
+		tx["data"] = "0xa905"
+		require.ErrorContains(t, sign(map[string]interface{}{"tx": tx}), "cannot be checked against allowedMethods")
+
+		tx = newTx()
+		tx["chainId"] = "1"
+		require.ErrorContains(t, sign(map[string]interface{}{"tx": tx}), "chain id 1 is not allowed")
+
+		require.ErrorContains(t, sign(map[string]interface{}{"txSerialized": "0x01"}), "txSerialized cannot be checked")
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, sign(map[string]interface{}{"txSerialized": "0x01"}))
+	})
+
+	t.Run("Test MsgHash", func(t *testing.T) {
+		_, err := testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":  username,
+			"address":   walletAddress,
+			"allowedTo": "0x3535353535353535353535353535353535353535",
+		})
+		require.NoError(t, err)
+
+		// The hash of a tx to any destination would otherwise pass as a msgHash.
+		require.ErrorContains(t, sign(map[string]interface{}{
+			"msgHash": "c4ad4a3e0fd9ba9e9e1f2ac9b05ccc2eb2ec6ad4a2e37d2ec74d4e5d6a8ee5b1",
+		}), "msgHash cannot be checked against the policy")
+		require.NoError(t, sign(map[string]interface{}{"tx": newTx()}))
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Test Icon Method", func(t *testing.T) {
+		policy := &kmsPolicy{AllowedMethods: []string{"transfer"}, AllowedChainIDs: []string{"1"}}
+
+		signer, err := newIconTxSigner(&kmsWallet{Address: "hx0000000000000000000000000000000000000001"}, defaultConfig(), map[string]interface{}{
+			"to":       "cx0000000000000000000000000000000000000002",
+			"nid":      "0x1",
+			"dataType": "call",
+			"data":     map[string]interface{}{"method": "approve"},
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, policy.checkTx(signer.summary()), "method approve is not allowed")
+
+		signer.tx["data"] = map[string]interface{}{"method": "transfer"}
+		require.NoError(t, policy.checkTx(signer.summary()))
+
+		signer.tx["dataType"] = "deploy"
+		require.ErrorContains(t, policy.checkTx(signer.summary()), "method deploy is not allowed")
+
+		etherSigner, err := newEtherTxSigner(&kmsWallet{Address: "0x0000000000000000000000000000000000000001"}, defaultConfig(), map[string]interface{}{
+			"chainId": "1",
+			"data":    "0x6080",
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, policy.checkTx(etherSigner.summary()), "method deploy is not allowed")
+	})
+
+	t.Run("Test Unknown Value", func(t *testing.T) {
+		policy := &kmsPolicy{MaxValue: "1000"}
+		require.ErrorContains(t, policy.checkTx(&txSummary{To: "hx0000000000000000000000000000000000000002"}), "value of the tx cannot be checked")
+
+		// An absent value is a value of 0.
+		signer, err := newIconTxSigner(&kmsWallet{Address: "hx0000000000000000000000000000000000000001"}, defaultConfig(), map[string]interface{}{
+			"to":  "hx0000000000000000000000000000000000000002",
+			"nid": "0x1",
+		})
+		require.NoError(t, err)
+		require.NoError(t, policy.checkTx(signer.summary()))
+	})
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username": username,
+		"address":  "0x0000000000000000000000000000000000000000",
+	})
+	require.Error(t, err)
+}
+
+func testPolicyRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		Path:        "wallet/policy",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
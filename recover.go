@@ -0,0 +1,104 @@
+package kms
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+func pathRecover(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "recover",
+			Fields: map[string]*framework.FieldSchema{
+				"msgHash": {
+					Type:        framework.TypeString,
+					Description: "the 32-byte message hash that was signed, expressed as a hex string",
+					Required:    true,
+				},
+				"signature": {
+					Type:        framework.TypeString,
+					Description: "65-byte compact signature R||S||V, expressed as a base64 or hex string",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathRecover,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathRecover,
+				},
+			},
+			HelpSynopsis:    pathRecoverHelpSynopsis,
+			HelpDescription: pathRecoverHelpDescription,
+		},
+	}
+}
+
+func (b *kmsBackend) pathRecover(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	hashBytes, err := hex.DecodeString(strings.TrimPrefix(d.Get("msgHash").(string), "0x"))
+	if err != nil || len(hashBytes) != 32 {
+		return nil, fmt.Errorf("invalid hash length")
+	}
+
+	signature, err := decodeSignature(d.Get("signature").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := chains.RecoverCompact(hashBytes, signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover: err=%v", err)
+	}
+
+	addresses := make(map[string]interface{})
+	for _, chainName := range chains.ChainNames() {
+		// Only secp256k1 signatures are recoverable.
+		if chains.ChainKeyType(chainName) != chains.KeySecp256k1 {
+			continue
+		}
+
+		address, err := chains.PublicKeyAddress(chainName, pubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		owner, err := getAddressOwner(ctx, req.Storage, address)
+		if err != nil {
+			return nil, err
+		}
+
+		info := map[string]interface{}{
+			"address": address,
+			"owned":   owner != nil,
+		}
+		if owner != nil {
+			info["username"] = owner.Username
+		}
+		addresses[string(chainName)] = info
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": hex.EncodeToString(pubKey.SerializeCompressed()),
+			"addresses":  addresses,
+		},
+	}, nil
+}
+
+const (
+	pathRecoverHelpSynopsis    = `Recovers the signer of a compact signature.`
+	pathRecoverHelpDescription = `
+This path lets you find who signed a message hash.
+The public key is recovered from the 65-byte compact signature R||S||V,
+and the address for each supported secp256k1 chain is returned together with
+whether a wallet of this mount owns it.
+Wallets stored before addresses were indexed are not reported as owned.
+`
+)
@@ -0,0 +1,79 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecover mocks the recovery of signers for kms.
+func TestRecover(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test Recover", func(t *testing.T) {
+		resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   username,
+			"chainName":  "icon",
+			"privateKey": "1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c",
+		})
+		require.NoError(t, err)
+
+		walletAddress := resp.Data["address"].(string)
+		msgHash := "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"
+
+		resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"msgHash":  msgHash,
+		})
+		require.NoError(t, err)
+
+		resp, err = testRecover(t, b, reqStorage, map[string]interface{}{
+			"msgHash":   msgHash,
+			"signature": resp.Data["signature"],
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0215526d990ed57973c2722b9c772b76e9d4ef0fcf4cea95b76844ffa925050ee6", resp.Data["public_key"])
+
+		addresses := resp.Data["addresses"].(map[string]interface{})
+		icon := addresses["icon"].(map[string]interface{})
+		require.Equal(t, walletAddress, icon["address"])
+		require.Equal(t, true, icon["owned"])
+		require.Equal(t, username, icon["username"])
+
+		ether := addresses["ether"].(map[string]interface{})
+		require.Equal(t, false, ether["owned"])
+		require.NotContains(t, addresses, "solana")
+
+		err = testWalletDelete(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+
+		owner, err := getAddressOwner(context.Background(), reqStorage, walletAddress)
+		require.NoError(t, err)
+		require.Nil(t, owner)
+	})
+}
+
+func testRecover(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        "recover",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
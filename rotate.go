@@ -0,0 +1,210 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	walletStatusActive  = "active"
+	walletStatusRetired = "retired"
+)
+
+func pathWalletRotate(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/rotate",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of the wallet to rotate",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathWalletRotate,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathWalletRotate,
+				},
+			},
+			HelpSynopsis:    pathWalletRotateHelpSynopsis,
+			HelpDescription: pathWalletRotateHelpDescription,
+		},
+	}
+}
+
+func (b *kmsBackend) pathWalletRotate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		if username = un.(string); username == "" {
+			return nil, fmt.Errorf("empty username in wallet")
+		}
+	} else {
+		return nil, fmt.Errorf("missing username in wallet")
+	}
+
+	var address string
+	if addr, ok := d.GetOk("address"); ok {
+		address = addr.(string)
+	} else {
+		return nil, fmt.Errorf("missing address in wallet")
+	}
+
+	walletPath := getWalletPath(username, address)
+	retired, err := getWallet(ctx, req, walletPath)
+	if err != nil {
+		return nil, err
+	}
+	if retired.Status == walletStatusRetired {
+		return nil, fmt.Errorf("wallet is already retired, rotate its successor %v", retired.Successor)
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := config.chainName(retired.ChainName); err != nil {
+		return nil, err
+	}
+
+	// The successor is derived from the same seed as an HD wallet.
+	var wallet *kmsWallet
+	if retired.SeedPath != "" {
+		wallet, err = b.createHDWallet(ctx, req, username, retired.ChainName)
+	} else {
+		wallet, err = createWallet(retired.ChainName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet. err=%v", err)
+	}
+	// The successor keeps the address options of the retired wallet.
+	wallet.Network, wallet.AddressType, wallet.HRP = retired.Network, retired.AddressType, retired.HRP
+	if err := wallet.deriveAddress(); err != nil {
+		return nil, err
+	}
+	wallet.KeyVersion = retired.KeyVersion + 1
+	wallet.Predecessor = retired.Address
+
+	if err := putWallet(ctx, req.Storage, username, wallet); err != nil {
+		return nil, err
+	}
+
+	// The successor keeps the restrictions of the retired wallet.
+	if err := copyStorageEntry(ctx, req.Storage, getPolicyPath(username, address), getPolicyPath(username, wallet.Address)); err != nil {
+		return nil, err
+	}
+	if err := copyStorageEntry(ctx, req.Storage, walletLimitScope(username, address).limitPath, walletLimitScope(username, wallet.Address).limitPath); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	retired.Status = walletStatusRetired
+	retired.RetiredAt = &now
+	retired.Successor = wallet.Address
+
+	if err := putWallet(ctx, req.Storage, username, retired); err != nil {
+		return nil, err
+	}
+	b.getKeyCache().remove(walletPath)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"address":     wallet.Address,
+			"key_version": wallet.KeyVersion,
+			"predecessor": retired.Address,
+		},
+	}, nil
+}
+
+// copyStorageEntry copies the entry at from to the key to, if it exists.
+func copyStorageEntry(ctx context.Context, s logical.Storage, from string, to string) error {
+	entry, err := s.Get(ctx, from)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return nil
+	}
+
+	return s.Put(ctx, &logical.StorageEntry{
+		Key:   to,
+		Value: entry.Value,
+	})
+}
+
+// walletHistory returns the versions of the wallet, from the first key to
+// the current one, following the predecessor and successor links of rotations.
+// The history stops at a destroyed version.
+func walletHistory(ctx context.Context, req *logical.Request, username string, wallet *kmsWallet) ([]map[string]interface{}, error) {
+	load := func(address string) (*kmsWallet, error) {
+		if entry, err := req.Storage.Get(ctx, getWalletPath(username, address)); err != nil || entry == nil {
+			return nil, err
+		}
+		return getWallet(ctx, req, getWalletPath(username, address))
+	}
+
+	versions := []*kmsWallet{wallet}
+	seen := map[string]bool{wallet.Address: true}
+	for w := wallet; w.Predecessor != "" && !seen[w.Predecessor]; {
+		predecessor, err := load(w.Predecessor)
+		if err != nil {
+			return nil, err
+		}
+		if predecessor == nil {
+			break
+		}
+		seen[predecessor.Address] = true
+		versions = append([]*kmsWallet{predecessor}, versions...)
+		w = predecessor
+	}
+	for w := wallet; w.Successor != "" && !seen[w.Successor]; {
+		successor, err := load(w.Successor)
+		if err != nil {
+			return nil, err
+		}
+		if successor == nil {
+			break
+		}
+		seen[successor.Address] = true
+		versions = append(versions, successor)
+		w = successor
+	}
+
+	history := make([]map[string]interface{}, 0, len(versions))
+	for _, version := range versions {
+		item := map[string]interface{}{
+			"address":     version.Address,
+			"key_version": version.KeyVersion,
+			"status":      version.Status,
+			"created_at":  version.CreatedAt,
+		}
+		if version.RetiredAt != nil {
+			item["retired_at"] = *version.RetiredAt
+		}
+		history = append(history, item)
+	}
+	return history, nil
+}
+
+const (
+	pathWalletRotateHelpSynopsis    = `Rotates the key of a user wallet.`
+	pathWalletRotateHelpDescription = `
+This path creates a new wallet for the same user and chain, and retires the given
+wallet, recording the address of its successor. The successor inherits the signing
+policy and the limits of the retired wallet.
+A retired wallet still verifies and can be read, but only signs when its policy
+sets allowRetired, so that funds can be migrated before the old key is deleted.
+Read the wallet to get the full version history.
+`
+)
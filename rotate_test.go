@@ -0,0 +1,119 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletRotate(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "icon",
+	})
+	require.NoError(t, err)
+	firstAddress := resp.Data["address"].(string)
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":    username,
+		"address":     firstAddress,
+		"denyMsgHash": true,
+	})
+	require.NoError(t, err)
+
+	resp, err = testWalletRotate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  firstAddress,
+	})
+	require.NoError(t, err)
+	secondAddress := resp.Data["address"].(string)
+	require.Equal(t, 2, resp.Data["key_version"])
+	require.Equal(t, firstAddress, resp.Data["predecessor"])
+
+	_, err = testWalletRotate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  firstAddress,
+	})
+	require.ErrorContains(t, err, "already retired")
+
+	resp, err = testWalletRotate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  secondAddress,
+	})
+	require.NoError(t, err)
+	thirdAddress := resp.Data["address"].(string)
+
+	t.Run("Test History", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      walletStoragePath,
+			Data:      map[string]interface{}{"username": username, "address": secondAddress},
+			Storage:   reqStorage,
+		})
+		require.NoError(t, err)
+		require.Equal(t, walletStatusRetired, resp.Data["status"])
+		require.Equal(t, firstAddress, resp.Data["predecessor"])
+		require.Equal(t, thirdAddress, resp.Data["successor"])
+
+		history := resp.Data["history"].([]map[string]interface{})
+		require.Len(t, history, 3)
+		for i, address := range []string{firstAddress, secondAddress, thirdAddress} {
+			require.Equal(t, address, history[i]["address"])
+			require.Equal(t, i+1, history[i]["key_version"])
+		}
+		require.Equal(t, walletStatusActive, history[2]["status"])
+		require.NotContains(t, history[2], "retired_at")
+	})
+
+	t.Run("Test Retired Signing", func(t *testing.T) {
+		signData := map[string]interface{}{
+			"username":     username,
+			"address":      firstAddress,
+			"txSerialized": "icx_sendTransaction.nid.0x1",
+		}
+		_, err := testSignCreate(t, b, reqStorage, signData)
+		require.ErrorContains(t, err, "wallet is retired, its successor is "+secondAddress)
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":     username,
+			"address":      firstAddress,
+			"allowRetired": true,
+		})
+		require.NoError(t, err)
+
+		_, err = testSignCreate(t, b, reqStorage, signData)
+		require.NoError(t, err)
+
+		// The successors inherit the policy of the retired wallet.
+		resp, err := testPolicyRequest(t, b, reqStorage, logical.ReadOperation, map[string]interface{}{
+			"username": username,
+			"address":  thirdAddress,
+		})
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["denyMsgHash"])
+		require.Equal(t, false, resp.Data["allowRetired"])
+	})
+}
+
+func testWalletRotate(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        "wallet/rotate",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -1,61 +1,93 @@
 package kms
 
 import (
+	"encoding/json"
 	"fmt"
-	"reflect"
 	"sort"
+	"strings"
 )
 
-func innerSerialize(txData map[string]interface{}, keys []string) (string, error) {
-	var ret string
+const (
+	iconTxMethod = "icx_sendTransaction"
+)
 
-	for _, key := range keys {
-		value := txData[key]
+var iconEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`.`, `\.`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`[`, `\[`,
+	`]`, `\]`,
+)
 
-		if value == nil {
-			ret += `\0`
-			continue
+func innerSerialize(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return `\0`, nil
+	case string:
+		return iconEscaper.Replace(v), nil
+	case json.Number:
+		return iconEscaper.Replace(v.String()), nil
+	case bool, int, int64, uint64, float64:
+		return iconEscaper.Replace(fmt.Sprint(v)), nil
+	case map[string]interface{}:
+		ret, err := serializeMap(v)
+		if err != nil {
+			return "", err
 		}
-
-		vt := reflect.ValueOf(value)
-		switch vt.Kind() {
-		case reflect.String:
-			fmt.Printf("%v:%v", key, value)
-			return fmt.Sprintf("%v.%v", key, value), nil
-		case reflect.Map:
-			innerData := vt.Interface().(map[string]interface{})
-			innerKeys := make([]string, 0, len(innerData))
-			for k := range innerData {
-				innerKeys = append(innerKeys, k)
+		return "{" + ret + "}", nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := innerSerialize(item)
+			if err != nil {
+				return "", err
 			}
-			return innerSerialize(innerData, innerKeys)
-		case reflect.Array:
-			var arrRet string
-			arrayData := vt.Interface().([]string)
-			for _, value := range arrayData {
-				arrRet += value
-				arrRet += "."
-			}
-			return arrRet, nil
-		default:
-			fmt.Printf("%v:%v", key, value)
-			return "", fmt.Errorf("invaild type: %v", vt)
+			items = append(items, s)
+		}
+		return "[" + strings.Join(items, ".") + "]", nil
+	case []string:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			items = append(items, item)
 		}
+		return innerSerialize(items)
 	}
-
-	return ret, nil
+	return "", fmt.Errorf("invalid type: %T", value)
 }
 
-// TODO : not implemented
-func Serialize(txData map[string]interface{}) string {
-	sortedKeys := make([]string, 0, len(txData))
+// serializeMap joins the sorted keys with their serialized values.
+func serializeMap(txData map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(txData))
 	for k := range txData {
-		sortedKeys = append(sortedKeys, k)
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	sort.Strings(sortedKeys)
+	items := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		s, err := innerSerialize(txData[key])
+		if err != nil {
+			return "", fmt.Errorf("%v: %w", key, err)
+		}
+		items = append(items, key, s)
+	}
+	return strings.Join(items, "."), nil
+}
 
-	innerSerialize(txData, sortedKeys)
+// Serialize returns the ICON v3 serialization of the transaction, which is
+// hashed to produce its signature. The signature field itself is excluded.
+func Serialize(txData map[string]interface{}) (string, error) {
+	params := make(map[string]interface{}, len(txData))
+	for k, v := range txData {
+		if k != "signature" {
+			params[k] = v
+		}
+	}
 
-	return ""
+	serialized, err := serializeMap(params)
+	if err != nil {
+		return "", err
+	}
+	return iconTxMethod + "." + serialized, nil
 }
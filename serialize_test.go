@@ -7,8 +7,6 @@ import (
 )
 
 func TestTxSerialize(t *testing.T) {
-	t.Skip()
-
 	txData := map[string]interface{}{
 		"version":   "0x3",
 		"from":      "hxbe258ceb872e08851f1f59694dac2558708ece11",
@@ -21,7 +19,8 @@ func TestTxSerialize(t *testing.T) {
 	}
 	t.Logf("txData=%v", txData)
 
-	serialized := Serialize(txData)
+	serialized, err := Serialize(txData)
+	require.NoError(t, err)
 	t.Logf("txData serialized=%v", serialized)
 
 	expected := "icx_sendTransaction" +
@@ -33,3 +32,40 @@ func TestTxSerialize(t *testing.T) {
 	t.Logf("serialized expected=%v", expected)
 	require.Equalf(t, expected, serialized, "TxSerialize: expected=%v actual=%v", expected, serialized)
 }
+
+func TestTxSerializeNested(t *testing.T) {
+	txData := map[string]interface{}{
+		"version":   "0x3",
+		"from":      "hxbe258ceb872e08851f1f59694dac2558708ece11",
+		"to":        "cxb0776ee37f5b45bfaea8cff1d8232fbb6122ec32",
+		"stepLimit": "0x12345",
+		"timestamp": "0x563a6cf330136",
+		"nid":       "0x1",
+		"nonce":     "0x1",
+		"dataType":  "call",
+		"data": map[string]interface{}{
+			"method": "transfer",
+			"params": map[string]interface{}{
+				"to":    "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b",
+				"value": "0x1",
+				"memo":  "a.b{c}[d]\\e",
+				"list":  []interface{}{"x", nil, map[string]interface{}{"k": "v"}},
+			},
+		},
+		"signature": "ignored",
+	}
+
+	serialized, err := Serialize(txData)
+	require.NoError(t, err)
+
+	expected := "icx_sendTransaction" +
+		".data.{method.transfer.params.{list.[x.\\0.{k.v}].memo.a\\.b\\{c\\}\\[d\\]\\\\e" +
+		".to.hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b.value.0x1}}" +
+		".dataType.call.from.hxbe258ceb872e08851f1f59694dac2558708ece11" +
+		".nid.0x1.nonce.0x1.stepLimit.0x12345.timestamp.0x563a6cf330136" +
+		".to.cxb0776ee37f5b45bfaea8cff1d8232fbb6122ec32.version.0x3"
+	require.Equal(t, expected, serialized)
+
+	_, err = Serialize(map[string]interface{}{"value": struct{}{}})
+	require.ErrorContains(t, err, "invalid type")
+}
@@ -2,10 +2,11 @@ package kms
 
 import (
 	"context"
+	b64 "encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 
-	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/perme-io/vault-plugin-secrets-kms/chains"
@@ -29,8 +30,8 @@ func pathSign(b *kmsBackend) []*framework.Path {
 				},
 				"chainName": {
 					Type:        framework.TypeString,
-					Description: "name of blockchain",
-					Required:    true,
+					Description: "name of blockchain, defaults to the chain of the wallet",
+					Required:    false,
 				},
 				"txSerialized": {
 					Type:        framework.TypeString,
@@ -42,6 +43,11 @@ func pathSign(b *kmsBackend) []*framework.Path {
 					Description: "an arbitrary 32-byte message hash to sign, expressed as a hex string",
 					Required:    false,
 				},
+				"tx": {
+					Type:        framework.TypeMap,
+					Description: "structured transaction to sign, with the fields of the chain",
+					Required:    false,
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.CreateOperation: &framework.PathOperation{
@@ -57,66 +63,319 @@ func pathSign(b *kmsBackend) []*framework.Path {
 	}
 }
 
+// signRequest is a signing request of wallet/sign, or an item of wallet/sign/batch.
+// Exactly one of Tx, TxSerialized and MsgHash is set.
+type signRequest struct {
+	Username     string                 `json:"username"`
+	Address      string                 `json:"address"`
+	ChainName    chains.ChainName       `json:"chain_name,omitempty"`
+	Tx           map[string]interface{} `json:"tx,omitempty"`
+	TxSerialized *string                `json:"tx_serialized,omitempty"`
+	MsgHash      *string                `json:"msg_hash,omitempty"`
+
+	// approved is set when the request reached the quorum of its approvers.
+	approved bool
+	// requestedBy is the entity that submitted an approved request.
+	requestedBy string
+}
+
+// signSession caches the wallets, keys and policies loaded while serving
+// a request, so that a batch signing with the same wallet decodes it once.
+type signSession struct {
+	b        *kmsBackend
+	config   *kmsConfig
+	wallets  map[string]*kmsWallet
+	keys     map[string]chains.PrivateKey
+	policies map[string]*kmsPolicy
+}
+
+func newSignSession(b *kmsBackend, config *kmsConfig) *signSession {
+	return &signSession{
+		b:        b,
+		config:   config,
+		wallets:  map[string]*kmsWallet{},
+		keys:     map[string]chains.PrivateKey{},
+		policies: map[string]*kmsPolicy{},
+	}
+}
+
 func (b *kmsBackend) pathSignCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	var username string
+	sr, err := signRequestFields(d)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := newSignSession(b, config).sign(ctx, req, sr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: data,
+	}, nil
+}
+
+// signRequestFields parses the fields of wallet/sign into a signRequest.
+func signRequestFields(d *framework.FieldData) (*signRequest, error) {
+	sr := new(signRequest)
 	if un, ok := d.GetOk("username"); ok {
-		username = un.(string)
+		sr.Username = un.(string)
 	} else {
 		return nil, fmt.Errorf("missing username in sign")
 	}
 
-	var address string
 	if addr, ok := d.GetOk("address"); ok {
-		address = addr.(string)
+		sr.Address = addr.(string)
 	} else {
 		return nil, fmt.Errorf("missing address in sign")
 	}
 
-	var chainName chains.ChainName
 	if wtype, ok := d.GetOk("chainName"); ok {
-		chainName = chains.ChainName(wtype.(string))
-	} else {
-		return nil, fmt.Errorf("missing chainName in sign")
+		sr.ChainName = chains.ChainName(wtype.(string))
 	}
 
-	var hashBytes []byte
-	if ts, ok := d.GetOk("txSerialized"); ok {
+	if tx, ok := d.GetOk("tx"); ok {
+		sr.Tx = tx.(map[string]interface{})
+	} else if ts, ok := d.GetOk("txSerialized"); ok {
 		txSerialized := ts.(string)
-		digest := sha3.Sum256([]byte(txSerialized))
-		hashBytes = digest[:]
+		sr.TxSerialized = &txSerialized
 	} else if mh, ok := d.GetOk("msgHash"); ok {
-		hexString := mh.(string)
-		hashBytes, _ = hex.DecodeString(hexString)
+		msgHash := mh.(string)
+		sr.MsgHash = &msgHash
+	}
+
+	return sr, nil
+}
+
+// sign produces the signature of the request and returns the response data.
+func (s *signSession) sign(ctx context.Context, req *logical.Request, sr *signRequest) (map[string]interface{}, error) {
+	wallet, chainName, err := s.wallet(ctx, req, sr.Username, sr.Address, sr.ChainName)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := s.policy(ctx, req, sr.Username, sr.Address)
+	if err != nil {
+		return nil, err
+	}
+	if policy.requiresApproval() && !sr.approved {
+		return nil, fmt.Errorf("wallet requires %v approvals, submit the request to wallet/sign/request", policy.RequiredApprovals)
+	}
+
+	var hashBytes []byte
+	var signer txSigner
+	var summary *txSummary
+	var value *big.Int
+	var kind string
+	if sr.Tx != nil {
+		if signer, err = newTxSigner(chainName, wallet, s.config, sr.Tx); err != nil {
+			return nil, err
+		}
+		summary = signer.summary()
+		if err := policy.checkTx(summary); err != nil {
+			return nil, err
+		}
+		value = summary.Value
+		hashBytes = signer.digest()
+		kind = ledgerKindTx
+	} else if sr.TxSerialized != nil {
+		if !txSerializedSupported(chainName) {
+			return nil, fmt.Errorf("txSerialized signing is not supported for %v, sign a structured tx instead", chainName)
+		}
+		if err := policy.checkTx(nil); err != nil {
+			return nil, err
+		}
+		hashBytes = txHash(chainName, *sr.TxSerialized)
+		kind = ledgerKindTxSerialized
+	} else if sr.MsgHash != nil {
+		// Chains of ed25519 keys sign the message itself, which a hash cannot stand for.
+		if chains.ChainKeyType(chainName) != chains.KeySecp256k1 {
+			return nil, fmt.Errorf("msgHash signing is not supported for %v, sign a structured tx instead", chainName)
+		}
+		if !s.config.AllowMsgHash {
+			return nil, fmt.Errorf("msgHash signing is not permitted")
+		}
+		if err := policy.checkMsgHash(); err != nil {
+			return nil, err
+		}
+		var err error
+		if hashBytes, err = hex.DecodeString(*sr.MsgHash); err != nil {
+			return nil, fmt.Errorf("invalid msgHash, expected hex: %w", err)
+		}
+		kind = ledgerKindMsgHash
 	} else {
-		return nil, fmt.Errorf("missing txSerialized or msgHash in sign")
+		return nil, fmt.Errorf("missing tx, txSerialized or msgHash in sign")
 	}
-	if len(hashBytes) != 32 {
+	// The digest of a structured tx of ed25519 chains is the message itself.
+	if len(hashBytes) != 32 && chains.ChainKeyType(chainName) == chains.KeySecp256k1 {
 		return nil, fmt.Errorf("invalid hash length")
 	}
 
-	walletPath := getWalletPath(username, address)
-	wallet, err := getWallet(ctx, req, walletPath)
+	chain, err := s.chain(ctx, req, sr.Username, sr.Address, wallet, chainName)
 	if err != nil {
 		return nil, err
 	}
 
-	if privKeyBytes, err := hex.DecodeString(wallet.PrivateKey); err == nil {
-		privateKey := secp256k1.PrivKeyFromBytes(privKeyBytes)
-		chain, err := chains.NewChain(chainName, privateKey)
+	if err := s.b.reserveUsage(ctx, req.Storage, signLimitScopes(sr.Username, sr.Address, chainName), value); err != nil {
+		return nil, err
+	}
+
+	signature, err := chain.SignCompact(hashBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: err=%v", err)
+	}
+
+	entityID := req.EntityID
+	if sr.requestedBy != "" {
+		entityID = sr.requestedBy
+	}
+	if err := s.record(ctx, req, sr.Username, sr.Address, &ledgerEntry{
+		ChainName: chainName,
+		Kind:      kind,
+		Digest:    hex.EncodeToString(hashBytes),
+		Signature: signature,
+		Tx:        newLedgerTxSummary(summary),
+		EntityID:  entityID,
+	}); err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{}
+
+	if signer != nil {
+		sigBytes, err := b64.StdEncoding.DecodeString(signature)
 		if err != nil {
 			return nil, err
 		}
 
-		if signature, err := chain.SignCompact(hashBytes); err == nil {
-			return &logical.Response{
-				Data: map[string]interface{}{
-					"signature": signature,
-				},
-			}, nil
+		signed, err := signer.signed(sigBytes)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range signed {
+			data[k] = v
+		}
+	}
+
+	// Signed transactions may return the signature in the encoding of the chain.
+	if _, ok := data["signature"]; !ok {
+		if data["signature"], err = s.config.encodeSignature(signature); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// wallet loads the wallet and resolves the chain it signs for.
+func (s *signSession) wallet(ctx context.Context, req *logical.Request, username string, address string, chainName chains.ChainName) (*kmsWallet, chains.ChainName, error) {
+	walletPath := getWalletPath(username, address)
+	wallet, ok := s.wallets[walletPath]
+	if !ok {
+		var privateKey chains.PrivateKey
+		if wallet, privateKey, ok = s.b.getKeyCache().get(walletPath); ok {
+			s.keys[walletPath] = privateKey
+		} else {
+			var err error
+			if wallet, err = getWallet(ctx, req, walletPath); err != nil {
+				return nil, "", err
+			}
+		}
+		s.wallets[walletPath] = wallet
+	}
+
+	policy, err := s.policy(ctx, req, username, address)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := policy.checkWallet(wallet); err != nil {
+		return nil, "", err
+	}
+
+	chainName, err = wallet.resolveChainName(chainName)
+	if err != nil {
+		return nil, "", err
+	}
+	if !s.config.isChainAllowed(chainName) {
+		return nil, "", fmt.Errorf("chainName %v is not allowed", chainName)
+	}
+
+	return wallet, chainName, nil
+}
+
+// record appends the signature to the ledger of the wallet. The signature
+// is not returned when it cannot be recorded.
+func (s *signSession) record(ctx context.Context, req *logical.Request, username string, address string, entry *ledgerEntry) error {
+	if err := s.b.appendLedger(ctx, req.Storage, username, address, entry); err != nil {
+		return fmt.Errorf("failed to record signature: %w", err)
+	}
+	return nil
+}
+
+// policy loads the signing policy of the wallet, or nil when it has none.
+func (s *signSession) policy(ctx context.Context, req *logical.Request, username string, address string) (*kmsPolicy, error) {
+	walletPath := getWalletPath(username, address)
+	if policy, ok := s.policies[walletPath]; ok {
+		return policy, nil
+	}
+
+	policy, err := walletPolicy(ctx, req.Storage, username, address)
+	if err != nil {
+		return nil, err
+	}
+	s.policies[walletPath] = policy
+	return policy, nil
+}
+
+// chain returns the chain signing with the key of the wallet.
+func (s *signSession) chain(ctx context.Context, req *logical.Request, username string, address string, wallet *kmsWallet, chainName chains.ChainName) (chains.Signer, error) {
+	privateKey, err := s.privateKey(ctx, req, username, address, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	return chains.NewSigner(chainName, privateKey)
+}
+
+func (s *signSession) privateKey(ctx context.Context, req *logical.Request, username string, address string, wallet *kmsWallet) (chains.PrivateKey, error) {
+	walletPath := getWalletPath(username, address)
+	privateKey, ok := s.keys[walletPath]
+	if !ok {
+		var err error
+		if privateKey, err = walletPrivateKey(ctx, req, wallet); err != nil {
+			return nil, fmt.Errorf("failed to sign: err=%v", err)
 		}
+		s.b.getKeyCache().add(walletPath, wallet, privateKey)
+		s.keys[walletPath] = privateKey
 	}
 
-	return nil, fmt.Errorf("faild to sign: err=%v", err)
+	return privateKey, nil
+}
+
+// txSerializedSupported reports whether txHash hashes the txSerialized of the
+// chain. Bitcoin, Cosmos, Tron and Solana transactions are hashed otherwise,
+// and are signed as a PSBT or a structured tx instead.
+func txSerializedSupported(chainName chains.ChainName) bool {
+	switch chainName {
+	case chains.ICON, chains.AERGO, chains.ETHER, chains.KAIA:
+		return true
+	}
+	return false
+}
+
+// txHash returns the hash of a serialized transaction to sign,
+// using the hash function of the chain.
+func txHash(chainName chains.ChainName, txSerialized string) []byte {
+	if chainName == chains.ETHER || chainName == chains.KAIA {
+		return chains.Keccak256([]byte(txSerialized))
+	}
+	digest := sha3.Sum256([]byte(txSerialized))
+	return digest[:]
 }
 
 const (
@@ -124,5 +383,16 @@ const (
 	pathSignHelpDescription = `
 This path lets you create a signature for sending a transaction.
 You can get a signature from the user's wallet by providing the username and txSerialized (or msgHash) fields.
+Provide the tx field instead to sign a structured transaction of the chain,
+such as an ICON v3 transaction object, an Ethereum legacy, EIP-2930 or EIP-1559
+transaction, an Aergo transaction body, a Cosmos SignDoc, a TRON transaction
+object with its raw_data_hex, a base64 Solana legacy or v0 message, or a Kaia
+value transfer, memo or smart contract execution transaction,
+and get the signed transaction back.
+Kaia transactions are signed with the role given in the tx, sender or feePayer.
+A fee payer signs the fee-delegated rawTx its sender signed.
+Solana wallets only sign a structured tx, since ed25519 signs the message itself.
+The signing policy of the wallet, see wallet/policy, is checked before signing,
+and the signature is counted against the limits of wallet/limit.
 `
 )
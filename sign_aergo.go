@@ -0,0 +1,116 @@
+package kms
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type aergoTxSigner struct {
+	tx   map[string]interface{}
+	body *chains.AergoTxBody
+}
+
+func newAergoTxSigner(wallet *kmsWallet, tx map[string]interface{}) (*aergoTxSigner, error) {
+	body := new(chains.AergoTxBody)
+
+	account, err := txString(tx, "account")
+	if err != nil {
+		return nil, err
+	}
+	if account == "" {
+		account = wallet.Address
+	} else if account != wallet.Address {
+		return nil, fmt.Errorf("tx account %v does not match wallet %v", account, wallet.Address)
+	}
+	if body.Account, err = chains.DecodeAergoAddress(account); err != nil {
+		return nil, err
+	}
+
+	recipient, err := txString(tx, "recipient")
+	if err != nil {
+		return nil, err
+	}
+	if body.Recipient, err = chains.DecodeAergoAddress(recipient); err != nil {
+		return nil, err
+	}
+
+	if body.Nonce, err = txUint64(tx, "nonce"); err != nil {
+		return nil, err
+	}
+	if body.Amount, err = txBigInt(tx, "amount"); err != nil {
+		return nil, err
+	}
+	if body.GasLimit, err = txUint64(tx, "gasLimit"); err != nil {
+		return nil, err
+	}
+	if body.GasPrice, err = txBigInt(tx, "gasPrice"); err != nil {
+		return nil, err
+	}
+
+	payload, err := txString(tx, "payload")
+	if err != nil {
+		return nil, err
+	}
+	body.Payload = base58.Decode(payload)
+	if payload != "" && len(body.Payload) == 0 {
+		return nil, fmt.Errorf("invalid payload in tx, expected base58")
+	}
+
+	chainIdHash, err := txString(tx, "chainIdHash")
+	if err != nil {
+		return nil, err
+	}
+	if body.ChainIdHash = base58.Decode(chainIdHash); len(body.ChainIdHash) != 32 {
+		return nil, fmt.Errorf("invalid chainIdHash in tx, expected base58 of 32 bytes")
+	}
+
+	if txType, ok := chains.AergoTxTypes[fmt.Sprint(tx["type"])]; ok {
+		body.Type = txType
+	} else {
+		txType, err := txUint64(tx, "type")
+		if err != nil {
+			return nil, err
+		}
+		body.Type = int32(txType)
+	}
+
+	signed := make(map[string]interface{}, len(tx)+1)
+	for k, v := range tx {
+		signed[k] = v
+	}
+	signed["account"] = account
+
+	return &aergoTxSigner{tx: signed, body: body}, nil
+}
+
+func (s *aergoTxSigner) digest() []byte {
+	return s.body.Hash()
+}
+
+// summary reads the called method from the JSON payload of a contract call.
+func (s *aergoTxSigner) summary() *txSummary {
+	summary := &txSummary{Value: txValue(s.body.Amount), Data: len(s.body.Payload) > 0}
+	summary.To, _ = s.tx["recipient"].(string)
+	summary.ChainID, _ = s.tx["chainIdHash"].(string)
+
+	var call struct {
+		Name string
+	}
+	if json.Unmarshal(s.body.Payload, &call) == nil {
+		summary.Method = call.Name
+	}
+	return summary
+}
+
+func (s *aergoTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	s.body.Sign = signature
+	s.tx["sign"] = base58.Encode(signature)
+
+	return map[string]interface{}{
+		"tx":      s.tx,
+		"tx_hash": base58.Encode(s.body.Hash()),
+	}, nil
+}
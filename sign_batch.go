@@ -0,0 +1,138 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+func pathSignBatch(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/sign/batch",
+			Fields: map[string]*framework.FieldSchema{
+				"items": {
+					Type:        framework.TypeSlice,
+					Description: "signing requests, each with username, address, chainName and one of tx, txSerialized or msgHash",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignBatch,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignBatch,
+				},
+			},
+			HelpSynopsis:    pathSignBatchHelpSynopsis,
+			HelpDescription: pathSignBatchHelpDescription,
+		},
+	}
+}
+
+// parseSignRequest returns the signing request of a batch item.
+func parseSignRequest(item interface{}) (*signRequest, error) {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid item in batch")
+	}
+
+	sr := new(signRequest)
+	var err error
+	if sr.Username, err = txString(fields, "username"); err != nil {
+		return nil, err
+	} else if sr.Username == "" {
+		return nil, fmt.Errorf("missing username in sign")
+	}
+
+	if sr.Address, err = txString(fields, "address"); err != nil {
+		return nil, err
+	} else if sr.Address == "" {
+		return nil, fmt.Errorf("missing address in sign")
+	}
+
+	chainName, err := txString(fields, "chainName")
+	if err != nil {
+		return nil, err
+	}
+	sr.ChainName = chains.ChainName(chainName)
+
+	if tx, ok := fields["tx"]; ok {
+		if sr.Tx, ok = tx.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("invalid tx in sign")
+		}
+	} else if _, ok := fields["txSerialized"]; ok {
+		txSerialized, err := txString(fields, "txSerialized")
+		if err != nil {
+			return nil, err
+		}
+		sr.TxSerialized = &txSerialized
+	} else if _, ok := fields["msgHash"]; ok {
+		msgHash, err := txString(fields, "msgHash")
+		if err != nil {
+			return nil, err
+		}
+		sr.MsgHash = &msgHash
+	}
+
+	return sr, nil
+}
+
+func (b *kmsBackend) pathSignBatch(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	items := d.Get("items").([]interface{})
+	if len(items) == 0 {
+		return nil, fmt.Errorf("missing items in batch")
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSignSession(b, config)
+
+	results := make([]map[string]interface{}, 0, len(items))
+	for i, item := range items {
+		result := map[string]interface{}{
+			"index": i,
+		}
+
+		data, err := func() (map[string]interface{}, error) {
+			sr, err := parseSignRequest(item)
+			if err != nil {
+				return nil, err
+			}
+			return session.sign(ctx, req, sr)
+		}()
+		if err != nil {
+			result["error"] = err.Error()
+		} else {
+			for k, v := range data {
+				result[k] = v
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"results": results,
+		},
+	}, nil
+}
+
+const (
+	pathSignBatchHelpSynopsis    = `Signs many transactions in one request.`
+	pathSignBatchHelpDescription = `
+This path lets you sign an array of items in one request. Each item has the
+fields of "wallet/sign": username, address, chainName and one of tx, txSerialized
+or msgHash. Results are returned in the order of the items, each with its index
+and either the signature or the error, so a failing item doesn't fail the batch.
+Wallets used by several items are read and decoded once.
+`
+)
@@ -0,0 +1,86 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignBatch mocks the batch signing for kms.
+func TestSignBatch(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "icon",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+	msgHash := "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8"
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"msgHash":  msgHash,
+	})
+	require.NoError(t, err)
+
+	expectedSignature := resp.Data["signature"]
+
+	resp, err = testSignBatch(t, b, reqStorage, map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{
+				"username": username,
+				"address":  walletAddress,
+				"msgHash":  msgHash,
+			},
+			map[string]interface{}{
+				"username": username,
+				"address":  "hx0000000000000000000000000000000000000000",
+				"msgHash":  msgHash,
+			},
+			map[string]interface{}{
+				"username":     username,
+				"address":      walletAddress,
+				"chainName":    "icon",
+				"txSerialized": "icx_sendTransaction.from." + walletAddress,
+			},
+			"invalid",
+		},
+	})
+	require.NoError(t, err)
+
+	results := resp.Data["results"].([]map[string]interface{})
+	require.Len(t, results, 4)
+
+	require.Equal(t, expectedSignature, results[0]["signature"])
+	require.Nil(t, results[0]["error"])
+
+	require.Equal(t, 1, results[1]["index"])
+	require.Contains(t, results[1]["error"], "not found wallet")
+
+	require.NotEmpty(t, results[2]["signature"])
+	require.Contains(t, results[3]["error"], "invalid item")
+}
+
+func testSignBatch(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/sign/batch",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -0,0 +1,137 @@
+package kms
+
+import (
+	b64 "encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type cosmosTxSigner struct {
+	signMode  string
+	signBytes []byte
+	chainID   string
+	msgs      []chains.CosmosMsg
+	publicKey string
+}
+
+// newCosmosTxSigner reads the document to sign from the tx: a base64 protobuf
+// SignDoc, or its bodyBytes, authInfoBytes, chainId and accountNumber, is signed
+// in SIGN_MODE_DIRECT, and an amino-JSON StdSignDoc object in SIGN_MODE_LEGACY_AMINO_JSON.
+func newCosmosTxSigner(wallet *kmsWallet, tx map[string]interface{}) (*cosmosTxSigner, error) {
+	pubKey, err := wallet.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	s := &cosmosTxSigner{publicKey: b64.StdEncoding.EncodeToString(pubKey.SerializeCompressed())}
+
+	switch signDoc := tx["signDoc"].(type) {
+	case map[string]interface{}:
+		s.signMode = chains.CosmosSignModeAminoJSON
+		if s.signBytes, err = chains.CosmosAminoSignBytes(signDoc); err != nil {
+			return nil, err
+		}
+		if s.msgs, err = chains.CosmosAminoMessages(signDoc); err != nil {
+			return nil, err
+		}
+		s.chainID = fmt.Sprint(signDoc["chain_id"])
+		return s, nil
+
+	case string:
+		s.signMode = chains.CosmosSignModeDirect
+		if s.signBytes, err = b64.StdEncoding.DecodeString(signDoc); err != nil {
+			return nil, fmt.Errorf("invalid signDoc in tx, expected base64: %w", err)
+		}
+
+	case nil:
+		s.signMode = chains.CosmosSignModeDirect
+		doc := new(chains.CosmosSignDoc)
+		if doc.BodyBytes, err = txBase64(tx, "bodyBytes"); err != nil {
+			return nil, err
+		}
+		if doc.AuthInfoBytes, err = txBase64(tx, "authInfoBytes"); err != nil {
+			return nil, err
+		}
+		if doc.ChainID, err = txString(tx, "chainId"); err != nil {
+			return nil, err
+		}
+		if doc.AccountNumber, err = txUint64(tx, "accountNumber"); err != nil {
+			return nil, err
+		}
+		s.signBytes = doc.Marshal()
+
+	default:
+		return nil, fmt.Errorf("invalid signDoc in tx: %v", signDoc)
+	}
+
+	doc, err := chains.ParseCosmosSignDoc(s.signBytes)
+	if err != nil {
+		return nil, err
+	}
+	if s.msgs, err = doc.Messages(); err != nil {
+		return nil, err
+	}
+	s.chainID = doc.ChainID
+
+	return s, nil
+}
+
+// txBase64 returns the base64 decoded field of the transaction, or nil when absent.
+func txBase64(tx map[string]interface{}, key string) ([]byte, error) {
+	v, err := txString(tx, key)
+	if err != nil || v == "" {
+		return nil, err
+	}
+
+	b, err := b64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v in tx, expected base64: %w", key, err)
+	}
+	return b, nil
+}
+
+func (s *cosmosTxSigner) digest() []byte {
+	return chains.CosmosSignHash(s.signBytes)
+}
+
+// summary covers every message of the transaction: the distinct message
+// types and recipients are joined with commas, so that a policy allowing
+// one of them does not allow a transaction mixing in others, and the
+// amounts of the bank sends are summed. Only the bank sends have a
+// recipient and an amount, so any other message makes the summary partial.
+func (s *cosmosTxSigner) summary() *txSummary {
+	summary := &txSummary{ChainID: s.chainID}
+
+	var types, recipients []string
+	for _, msg := range s.msgs {
+		if !containsFold(types, msg.Type) {
+			types = append(types, msg.Type)
+		}
+		if msg.To == "" || msg.Amount == nil {
+			summary.Partial = true
+		}
+		if msg.To != "" && !containsFold(recipients, msg.To) {
+			recipients = append(recipients, msg.To)
+		}
+		if msg.Amount != nil {
+			if summary.Value == nil {
+				summary.Value = new(big.Int)
+			}
+			summary.Value.Add(summary.Value, msg.Amount)
+		}
+	}
+	summary.Method = strings.Join(types, ",")
+	summary.To = strings.Join(recipients, ",")
+
+	return summary
+}
+
+func (s *cosmosTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"sign_mode":  s.signMode,
+		"sign_bytes": b64.StdEncoding.EncodeToString(s.signBytes),
+		"public_key": s.publicKey,
+	}, nil
+}
@@ -0,0 +1,208 @@
+package kms
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type etherTxSigner struct {
+	tx   *chains.EtherTx
+	hash []byte
+}
+
+// decodeHexField decodes a 0x-prefixed hex field of the transaction,
+// checking its length when size is positive.
+func decodeHexField(key string, value string, size int) ([]byte, error) {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v in tx: %w", key, err)
+	}
+	if size > 0 && len(decoded) != size {
+		return nil, fmt.Errorf("invalid %v length in tx: %v", key, len(decoded))
+	}
+	return decoded, nil
+}
+
+func newEtherTxSigner(wallet *kmsWallet, config *kmsConfig, tx map[string]interface{}) (*etherTxSigner, error) {
+	etherTx, err := parseEtherTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := txString(tx, "from")
+	if err != nil {
+		return nil, err
+	}
+	if from != "" && !strings.EqualFold(from, wallet.Address) {
+		return nil, fmt.Errorf("tx from %v does not match wallet %v", from, wallet.Address)
+	}
+
+	// Legacy txs are signed with EIP-155 too, so that they cannot be replayed on other chains.
+	if etherTx.ChainID == nil {
+		chainID, ok := config.networkID(chains.ETHER)
+		if !ok {
+			return nil, fmt.Errorf("missing chainId in tx")
+		}
+		etherTx.ChainID = new(big.Int).SetUint64(chainID)
+	}
+
+	hash, err := etherTx.SigningHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &etherTxSigner{tx: etherTx, hash: hash}, nil
+}
+
+func parseEtherTx(tx map[string]interface{}) (*chains.EtherTx, error) {
+	etherTx := new(chains.EtherTx)
+
+	txType, err := txUint64(tx, "type")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tx["type"]; !ok {
+		if _, ok := tx["maxFeePerGas"]; ok {
+			txType = chains.EtherDynamicFeeTxType
+		} else if _, ok := tx["accessList"]; ok {
+			txType = chains.EtherAccessListTxType
+		}
+	}
+	if txType > chains.EtherDynamicFeeTxType {
+		return nil, fmt.Errorf("unsupported ether tx type: %v", txType)
+	}
+	etherTx.Type = byte(txType)
+
+	if etherTx.ChainID, err = txBigInt(tx, "chainId"); err != nil {
+		return nil, err
+	}
+	if etherTx.Nonce, err = txUint64(tx, "nonce"); err != nil {
+		return nil, err
+	}
+	if etherTx.GasPrice, err = txBigInt(tx, "gasPrice"); err != nil {
+		return nil, err
+	}
+	if etherTx.MaxPriorityFeePerGas, err = txBigInt(tx, "maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	if etherTx.MaxFeePerGas, err = txBigInt(tx, "maxFeePerGas"); err != nil {
+		return nil, err
+	}
+	if etherTx.Value, err = txBigInt(tx, "value"); err != nil {
+		return nil, err
+	}
+
+	gasKey := "gas"
+	if _, ok := tx[gasKey]; !ok {
+		gasKey = "gasLimit"
+	}
+	if etherTx.Gas, err = txUint64(tx, gasKey); err != nil {
+		return nil, err
+	}
+
+	to, err := txString(tx, "to")
+	if err != nil {
+		return nil, err
+	}
+	if to != "" {
+		if etherTx.To, err = decodeHexField("to", to, 20); err != nil {
+			return nil, err
+		}
+	}
+
+	dataKey := "data"
+	if _, ok := tx[dataKey]; !ok {
+		dataKey = "input"
+	}
+	data, err := txString(tx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if etherTx.Data, err = decodeHexField(dataKey, data, 0); err != nil {
+		return nil, err
+	}
+
+	if accessList, ok := tx["accessList"]; ok && accessList != nil {
+		tuples, ok := accessList.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid accessList in tx")
+		}
+		for _, t := range tuples {
+			tuple, ok := t.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid accessList in tx")
+			}
+
+			var accessTuple chains.EtherAccessTuple
+			address, err := txString(tuple, "address")
+			if err != nil {
+				return nil, err
+			}
+			if accessTuple.Address, err = decodeHexField("accessList address", address, 20); err != nil {
+				return nil, err
+			}
+
+			keys, _ := tuple["storageKeys"].([]interface{})
+			for _, k := range keys {
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid accessList storageKeys in tx")
+				}
+				keyBytes, err := decodeHexField("accessList storageKeys", key, 32)
+				if err != nil {
+					return nil, err
+				}
+				accessTuple.StorageKeys = append(accessTuple.StorageKeys, keyBytes)
+			}
+			etherTx.AccessList = append(etherTx.AccessList, accessTuple)
+		}
+	}
+
+	return etherTx, nil
+}
+
+func (s *etherTxSigner) digest() []byte {
+	return s.hash
+}
+
+// summary reports the selector of a contract call as the method, and
+// "deploy" for a contract creation, whose data is the contract code.
+func (s *etherTxSigner) summary() *txSummary {
+	summary := &txSummary{Value: txValue(s.tx.Value), Data: len(s.tx.Data) > 0}
+	switch {
+	case s.tx.To == nil:
+		summary.Method = "deploy"
+	case len(s.tx.Data) >= 4:
+		summary.To = "0x" + hex.EncodeToString(s.tx.To)
+		summary.Method = "0x" + hex.EncodeToString(s.tx.Data[:4])
+	default:
+		summary.To = "0x" + hex.EncodeToString(s.tx.To)
+	}
+	if s.tx.ChainID != nil {
+		summary.ChainID = s.tx.ChainID.String()
+	}
+	return summary
+}
+
+func (s *etherTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	if err := s.tx.SetSignature(signature); err != nil {
+		return nil, err
+	}
+
+	rawTx, err := s.tx.RawTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"raw_tx":  "0x" + hex.EncodeToString(rawTx),
+		"tx_hash": "0x" + hex.EncodeToString(chains.Keccak256(rawTx)),
+		"v":       "0x" + s.tx.V.Text(16),
+		"r":       "0x" + s.tx.R.Text(16),
+		"s":       "0x" + s.tx.S.Text(16),
+	}, nil
+}
@@ -0,0 +1,96 @@
+package kms
+
+import (
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type iconTxSigner struct {
+	tx         map[string]interface{}
+	serialized string
+	hash       []byte
+	value      *big.Int
+}
+
+func newIconTxSigner(wallet *kmsWallet, config *kmsConfig, tx map[string]interface{}) (*iconTxSigner, error) {
+	signed := make(map[string]interface{}, len(tx)+1)
+	for k, v := range tx {
+		signed[k] = v
+	}
+
+	from, err := txString(tx, "from")
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		signed["from"] = wallet.Address
+	} else if from != wallet.Address {
+		return nil, fmt.Errorf("tx from %v does not match wallet %v", from, wallet.Address)
+	}
+
+	if _, ok := signed["version"]; !ok {
+		signed["version"] = "0x3"
+	}
+
+	if _, ok := signed["nid"]; !ok {
+		nid, ok := config.networkID(chains.ICON)
+		if !ok {
+			return nil, fmt.Errorf("missing nid in tx")
+		}
+		signed["nid"] = fmt.Sprintf("0x%x", nid)
+	}
+
+	value, err := txBigInt(signed, "value")
+	if err != nil {
+		return nil, err
+	}
+
+	serialized, err := Serialize(signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize tx: %w", err)
+	}
+
+	return &iconTxSigner{
+		tx:         signed,
+		serialized: serialized,
+		hash:       txHash(chains.ICON, serialized),
+		value:      value,
+	}, nil
+}
+
+func (s *iconTxSigner) digest() []byte {
+	return s.hash
+}
+
+// summary reports the method of a call as the method, and the dataType
+// of the other txs carrying data: deploy, message or deposit.
+func (s *iconTxSigner) summary() *txSummary {
+	summary := &txSummary{Value: txValue(s.value)}
+	summary.To, _ = s.tx["to"].(string)
+	summary.ChainID, _ = s.tx["nid"].(string)
+
+	dataType, _ := s.tx["dataType"].(string)
+	summary.Data = dataType != "" || s.tx["data"] != nil
+	if dataType == "call" {
+		if data, ok := s.tx["data"].(map[string]interface{}); ok {
+			summary.Method, _ = data["method"].(string)
+		}
+	} else {
+		summary.Method = dataType
+	}
+	return summary
+}
+
+func (s *iconTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	s.tx["signature"] = b64.StdEncoding.EncodeToString(signature)
+
+	return map[string]interface{}{
+		"tx":            s.tx,
+		"tx_hash":       "0x" + hex.EncodeToString(s.hash),
+		"tx_serialized": s.serialized,
+	}, nil
+}
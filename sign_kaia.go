@@ -0,0 +1,271 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+// Roles a wallet signs Kaia transactions as.
+const (
+	kaiaRoleSender   = "sender"
+	kaiaRoleFeePayer = "feePayer"
+)
+
+type kaiaTxSigner struct {
+	tx   *chains.KaiaTx
+	role string
+	hash []byte
+}
+
+// newKaiaTxSigner reads the Kaia transaction to sign, given either field by
+// field or as the rawTx signed by its sender or other signers, and signs it
+// as the role of the tx: sender, or feePayer of a fee-delegated type. The
+// role defaults to feePayer for raw transactions and to sender otherwise.
+//
+// The account of the role, from or feePayer, defaults to the wallet address.
+// It may be another address, since Kaia accounts with role-based or
+// decoupled keys sign with keys their address is not derived from.
+func newKaiaTxSigner(wallet *kmsWallet, config *kmsConfig, tx map[string]interface{}) (*kaiaTxSigner, error) {
+	role, err := txString(tx, "role")
+	if err != nil {
+		return nil, err
+	}
+
+	rawTx, err := txString(tx, "rawTx")
+	if err != nil {
+		return nil, err
+	}
+
+	var kaiaTx *chains.KaiaTx
+	if rawTx != "" {
+		raw, err := decodeHexField("rawTx", rawTx, 0)
+		if err != nil {
+			return nil, err
+		}
+		if kaiaTx, err = chains.ParseKaiaRawTx(raw); err != nil {
+			return nil, err
+		}
+		if role == "" {
+			role = kaiaRoleFeePayer
+		}
+	} else if kaiaTx, err = parseKaiaTx(tx, role); err != nil {
+		return nil, err
+	}
+
+	chainID, err := txBigInt(tx, "chainId")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case chainID == nil:
+	case kaiaTx.ChainID == nil:
+		kaiaTx.ChainID = chainID
+	case kaiaTx.ChainID.Cmp(chainID) != 0:
+		return nil, fmt.Errorf("tx chainId %v does not match the signatures of chain id %v", chainID, kaiaTx.ChainID)
+	}
+	if kaiaTx.ChainID == nil {
+		networkID, ok := config.networkID(chains.KAIA)
+		if !ok {
+			return nil, fmt.Errorf("missing chainId in tx")
+		}
+		kaiaTx.ChainID = new(big.Int).SetUint64(networkID)
+	}
+
+	address, err := decodeHexField("wallet address", wallet.Address, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash []byte
+	switch role {
+	case "", kaiaRoleSender:
+		role = kaiaRoleSender
+		if kaiaTx.From == nil {
+			kaiaTx.From = address
+		}
+		hash, err = kaiaTx.SigningHash()
+	case kaiaRoleFeePayer:
+		if rawTx != "" {
+			feePayer, err := txString(tx, "feePayer")
+			if err != nil {
+				return nil, err
+			}
+			if feePayer != "" {
+				account, err := decodeHexField("feePayer", feePayer, 20)
+				if err != nil {
+					return nil, err
+				}
+				if kaiaTx.FeePayer != nil && !bytes.Equal(kaiaTx.FeePayer, account) {
+					return nil, fmt.Errorf("tx feePayer %v does not match the fee payer of rawTx", feePayer)
+				}
+				kaiaTx.FeePayer = account
+			}
+		}
+		if kaiaTx.FeePayer == nil {
+			kaiaTx.FeePayer = address
+		}
+		hash, err = kaiaTx.FeePayerSigningHash()
+	default:
+		return nil, fmt.Errorf("invalid role in tx: %v", role)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &kaiaTxSigner{tx: kaiaTx, role: role, hash: hash}, nil
+}
+
+// parseKaiaTx reads the fields of the transaction. Without a type, it is a
+// value transfer, or a smart contract execution when it has an input, made
+// fee-delegated by a feePayer, a feeRatio or the feePayer role.
+func parseKaiaTx(tx map[string]interface{}, role string) (*chains.KaiaTx, error) {
+	kaiaTx := new(chains.KaiaTx)
+
+	var err error
+	if kaiaTx.Nonce, err = txUint64(tx, "nonce"); err != nil {
+		return nil, err
+	}
+	if kaiaTx.GasPrice, err = txBigInt(tx, "gasPrice"); err != nil {
+		return nil, err
+	}
+	if kaiaTx.Value, err = txBigInt(tx, "value"); err != nil {
+		return nil, err
+	}
+	if kaiaTx.FeeRatio, err = txUint64(tx, "feeRatio"); err != nil {
+		return nil, err
+	}
+
+	gasKey := "gas"
+	if _, ok := tx[gasKey]; !ok {
+		gasKey = "gasLimit"
+	}
+	if kaiaTx.Gas, err = txUint64(tx, gasKey); err != nil {
+		return nil, err
+	}
+
+	for key, field := range map[string]*[]byte{"to": &kaiaTx.To, "from": &kaiaTx.From, "feePayer": &kaiaTx.FeePayer} {
+		value, err := txString(tx, key)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			continue
+		}
+		if *field, err = decodeHexField(key, value, 20); err != nil {
+			return nil, err
+		}
+	}
+	if kaiaTx.To == nil {
+		return nil, fmt.Errorf("missing to in tx")
+	}
+
+	inputKey := "input"
+	if _, ok := tx[inputKey]; !ok {
+		inputKey = "data"
+	}
+	input, err := txString(tx, inputKey)
+	if err != nil {
+		return nil, err
+	}
+	if kaiaTx.Input, err = decodeHexField(inputKey, input, 0); err != nil {
+		return nil, err
+	}
+
+	if _, ok := tx["type"]; ok {
+		txType, err := txUint64(tx, "type")
+		if err != nil {
+			return nil, err
+		}
+		if txType > 0xff || !chains.KaiaTxTypeSupported(byte(txType)) {
+			return nil, fmt.Errorf("unsupported kaia tx type: %v", txType)
+		}
+		kaiaTx.Type = byte(txType)
+	} else {
+		kaiaTx.Type = chains.KaiaValueTransferTxType
+		if len(kaiaTx.Input) > 0 {
+			kaiaTx.Type = chains.KaiaSmartContractExecutionTxType
+		}
+		if kaiaTx.FeeRatio > 0 {
+			kaiaTx.Type += chains.KaiaFeeDelegatedValueTransferWithRatioTxType - chains.KaiaValueTransferTxType
+		} else if kaiaTx.FeePayer != nil || role == kaiaRoleFeePayer {
+			kaiaTx.Type += chains.KaiaFeeDelegatedValueTransferTxType - chains.KaiaValueTransferTxType
+		}
+	}
+
+	if len(kaiaTx.Input) > 0 && !kaiaTx.HasInput() {
+		return nil, fmt.Errorf("input not supported by kaia tx type %v", kaiaTx.Type)
+	}
+	if kaiaTx.FeePayer != nil && !kaiaTx.FeeDelegated() {
+		return nil, fmt.Errorf("feePayer not supported by kaia tx type %v", kaiaTx.Type)
+	}
+
+	return kaiaTx, nil
+}
+
+func (s *kaiaTxSigner) digest() []byte {
+	return s.hash
+}
+
+// summary reports the recipient and value of the transaction, which a fee
+// payer is checked against as well as the sender. The method is the selector
+// of a smart contract execution, or memo for the input of a memo transfer.
+func (s *kaiaTxSigner) summary() *txSummary {
+	summary := &txSummary{
+		To:      "0x" + hex.EncodeToString(s.tx.To),
+		Value:   txValue(s.tx.Value),
+		ChainID: s.tx.ChainID.String(),
+		Data:    len(s.tx.Input) > 0,
+	}
+	switch s.tx.BaseType() {
+	case chains.KaiaSmartContractExecutionTxType:
+		if len(s.tx.Input) >= 4 {
+			summary.Method = "0x" + hex.EncodeToString(s.tx.Input[:4])
+		}
+	case chains.KaiaValueTransferMemoTxType:
+		summary.Method = "memo"
+	}
+	return summary
+}
+
+// signed returns the raw transaction with the signature of the wallet
+// added to the signatures of its role. The transaction is complete once
+// the sender and, when fee-delegated, the fee payer have signed.
+func (s *kaiaTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	var sig chains.KaiaSignature
+	var err error
+	if s.role == kaiaRoleFeePayer {
+		sig, err = s.tx.AddFeePayerSignature(signature)
+	} else {
+		sig, err = s.tx.AddSignature(signature)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rawTx, err := s.tx.RawTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	account := s.tx.From
+	if s.role == kaiaRoleFeePayer {
+		account = s.tx.FeePayer
+	}
+	complete := len(s.tx.Signatures) > 0 && (!s.tx.FeeDelegated() || len(s.tx.FeePayerSignatures) > 0)
+
+	return map[string]interface{}{
+		"raw_tx":   "0x" + hex.EncodeToString(rawTx),
+		"tx_hash":  "0x" + hex.EncodeToString(chains.Keccak256(rawTx)),
+		"tx_type":  int(s.tx.Type),
+		"role":     s.role,
+		"account":  "0x" + hex.EncodeToString(account),
+		"complete": complete,
+		"v":        "0x" + sig.V.Text(16),
+		"r":        "0x" + sig.R.Text(16),
+		"s":        "0x" + sig.S.Text(16),
+	}, nil
+}
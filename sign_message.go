@@ -0,0 +1,185 @@
+package kms
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+const (
+	messageEncodingUTF8 = "utf8"
+	messageEncodingHex  = "hex"
+)
+
+func pathSignMessage(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/sign/message",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain, defaults to the chain of the wallet",
+					Required:    false,
+				},
+				"message": {
+					Type:        framework.TypeString,
+					Description: "message to sign",
+					Required:    true,
+				},
+				"messageEncoding": {
+					Type:        framework.TypeString,
+					Description: "encoding of the message, utf8 or hex",
+					Required:    false,
+					Default:     messageEncodingUTF8,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignMessage,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignMessage,
+				},
+			},
+			HelpSynopsis:    pathSignMessageHelpSynopsis,
+			HelpDescription: pathSignMessageHelpDescription,
+		},
+	}
+}
+
+// walletSignature converts the base64 signature of a chain to the format
+// returned by the wallets of the chain.
+func walletSignature(chainName chains.ChainName, config *kmsConfig, signature string) (string, error) {
+	switch chainName {
+	case chains.ETHER, chains.KAIA:
+		sigBytes, err := b64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			return "", err
+		}
+		sigBytes[len(sigBytes)-1] += ethSignatureVOffset
+		return "0x" + hex.EncodeToString(sigBytes), nil
+	}
+	return config.encodeSignature(signature)
+}
+
+func (b *kmsBackend) pathSignMessage(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		username = un.(string)
+	} else {
+		return nil, fmt.Errorf("missing username in sign")
+	}
+
+	var address string
+	if addr, ok := d.GetOk("address"); ok {
+		address = addr.(string)
+	} else {
+		return nil, fmt.Errorf("missing address in sign")
+	}
+
+	var message []byte
+	switch encoding := d.Get("messageEncoding").(string); encoding {
+	case messageEncodingUTF8:
+		message = []byte(d.Get("message").(string))
+	case messageEncodingHex:
+		var err error
+		if message, err = hex.DecodeString(strings.TrimPrefix(d.Get("message").(string), "0x")); err != nil {
+			return nil, fmt.Errorf("invalid message in sign: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid messageEncoding: %v", encoding)
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSignSession(b, config)
+	wallet, chainName, err := session.wallet(ctx, req, username, address, chains.ChainName(d.Get("chainName").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := session.policy(ctx, req, username, address)
+	if err != nil {
+		return nil, err
+	}
+	if policy.requiresApproval() {
+		return nil, fmt.Errorf("wallet requires %v approvals, which message signing does not support", policy.RequiredApprovals)
+	}
+	if err := policy.checkMessage("message"); err != nil {
+		return nil, err
+	}
+
+	digest, err := chains.MessageHash(chainName, message)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := session.chain(ctx, req, username, address, wallet, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature counts against the limits, with no value.
+	if err := b.reserveUsage(ctx, req.Storage, signLimitScopes(username, address, chainName), new(big.Int)); err != nil {
+		return nil, err
+	}
+
+	signature, err := chain.SignCompact(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: err=%v", err)
+	}
+
+	if err := session.record(ctx, req, username, address, &ledgerEntry{
+		ChainName: chainName,
+		Kind:      ledgerKindMessage,
+		Digest:    hex.EncodeToString(digest),
+		Signature: signature,
+		EntityID:  req.EntityID,
+	}); err != nil {
+		return nil, err
+	}
+
+	if signature, err = walletSignature(chainName, config, signature); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+			"digest":    "0x" + hex.EncodeToString(digest),
+		},
+	}, nil
+}
+
+const (
+	pathSignMessageHelpSynopsis    = `Signs a human-readable message with a wallet.`
+	pathSignMessageHelpDescription = `
+This path lets you produce personal_sign style signatures, such as for login-with-wallet.
+The message is prefixed as the chain requires, "\x19Ethereum Signed Message:\n<len>"
+for Ethereum (EIP-191), "\x19ICON Signed Message:\n<len>" for ICON,
+"\x19TRON Signed Message:\n<len>" for TRON and "\x19Klaytn Signed Message:\n<len>"
+for Kaia, and hashed with the hash function of the chain. Ethereum and Kaia
+signatures are returned as hex strings with V of 27 or 28, the others in the
+configured signature encoding.
+`
+)
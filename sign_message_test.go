@@ -0,0 +1,121 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignMessage mocks the personal message signing for kms.
+func TestSignMessage(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	for _, chainName := range []string{"ether", "icon"} {
+		t.Run("Test Sign Message "+chainName, func(t *testing.T) {
+			resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+				"username":  username,
+				"chainName": chainName,
+			})
+			require.NoError(t, err)
+
+			walletAddress := resp.Data["address"].(string)
+
+			resp, err = testSignMessage(t, b, reqStorage, map[string]interface{}{
+				"username": username,
+				"address":  walletAddress,
+				"message":  "Sign in to example.com",
+			})
+			require.NoError(t, err)
+
+			resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+				"address":   walletAddress,
+				"chainName": chainName,
+				"msgHash":   resp.Data["digest"],
+				"signature": resp.Data["signature"],
+			})
+			require.NoError(t, err)
+			require.Equal(t, true, resp.Data["valid"])
+		})
+	}
+
+	t.Run("Test Sign Message Limit", func(t *testing.T) {
+		resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+		})
+		require.NoError(t, err)
+		walletAddress := resp.Data["address"].(string)
+
+		_, err = testLimitRequest(t, b, reqStorage, "wallet/limit", logical.CreateOperation, map[string]interface{}{
+			"username":      username,
+			"address":       walletAddress,
+			"maxSignatures": 1,
+			"maxValue":      "0",
+		})
+		require.NoError(t, err)
+
+		sign := func() error {
+			_, err := testSignMessage(t, b, reqStorage, map[string]interface{}{
+				"username": username,
+				"address":  walletAddress,
+				"message":  "Sign in to example.com",
+			})
+			return err
+		}
+		require.NoError(t, sign())
+		require.ErrorContains(t, sign(), "wallet limit exceeded: 1 signatures")
+	})
+
+	t.Run("Test Sign Message Ether Prefix", func(t *testing.T) {
+		_, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   username,
+			"chainName":  "ether",
+			"privateKey": "4646464646464646464646464646464646464646464646464646464646464646",
+		})
+		require.NoError(t, err)
+
+		resp, err := testSignMessage(t, b, reqStorage, map[string]interface{}{
+			"username":        username,
+			"address":         "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f",
+			"message":         "0x68656c6c6f",
+			"messageEncoding": "hex",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0x50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750", resp.Data["digest"])
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":    username,
+			"address":     "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f",
+			"denyMsgHash": true,
+		})
+		require.NoError(t, err)
+
+		_, err = testSignMessage(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f",
+			"message":  "hello",
+		})
+		require.ErrorContains(t, err, "policy denied: message signing is not permitted")
+	})
+}
+
+func testSignMessage(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/sign/message",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -0,0 +1,219 @@
+package kms
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+const (
+	ledgerKindPsbt = "psbt"
+)
+
+func pathSignPsbt(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/sign/psbt",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of the bitcoin wallet",
+					Required:    true,
+				},
+				"psbt": {
+					Type:        framework.TypeString,
+					Description: "base64 encoded partially signed bitcoin transaction (BIP-174)",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignPsbt,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignPsbt,
+				},
+			},
+			HelpSynopsis:    pathSignPsbtHelpSynopsis,
+			HelpDescription: pathSignPsbtHelpDescription,
+		},
+	}
+}
+
+func (b *kmsBackend) pathSignPsbt(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		username = un.(string)
+	} else {
+		return nil, fmt.Errorf("missing username in sign")
+	}
+
+	var address string
+	if addr, ok := d.GetOk("address"); ok {
+		address = addr.(string)
+	} else {
+		return nil, fmt.Errorf("missing address in sign")
+	}
+
+	packet, err := chains.ParsePsbtBase64(d.Get("psbt").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSignSession(b, config)
+	wallet, chainName, err := session.wallet(ctx, req, username, address, chains.BITCOIN)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := session.policy(ctx, req, username, address)
+	if err != nil {
+		return nil, err
+	}
+	if policy.requiresApproval() {
+		return nil, fmt.Errorf("wallet requires %v approvals, which PSBT signing does not support", policy.RequiredApprovals)
+	}
+
+	privateKey, err := session.privateKey(ctx, req, username, address, wallet)
+	if err != nil {
+		return nil, err
+	}
+	secpKey, ok := privateKey.(*secp256k1.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("wallet %v does not hold a secp256k1 key", address)
+	}
+
+	// The signatures are computed before the policy is checked and any usage
+	// is reserved, since the inputs they sign are the value the wallet spends.
+	// The signed PSBT is only returned once both pass.
+	signatures, err := chains.SignPsbt(packet, secpKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: err=%v", err)
+	}
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("no input of the psbt is owned by wallet %v", address)
+	}
+
+	summaries, value, err := psbtSummaries(packet, wallet, signatures)
+	if err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		if err := policy.checkTx(summary); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.reserveUsage(ctx, req.Storage, signLimitScopes(username, address, chainName), value); err != nil {
+		return nil, err
+	}
+
+	txSummary := newLedgerTxSummary(summaries[0])
+
+	inputs := make([]int, 0, len(signatures))
+	for _, signature := range signatures {
+		if err := session.record(ctx, req, username, address, &ledgerEntry{
+			ChainName: chainName,
+			Kind:      ledgerKindPsbt,
+			Digest:    hex.EncodeToString(signature.SigHash),
+			Signature: b64.StdEncoding.EncodeToString(signature.Signature),
+			Tx:        txSummary,
+			EntityID:  req.EntityID,
+		}); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, signature.Index)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"psbt":          packet.Base64(),
+			"signed_inputs": inputs,
+		},
+	}, nil
+}
+
+// psbtSummaries returns the outputs of the PSBT paying outside the wallet, as
+// the policy checks them, and the value the wallet spends in satoshis: the
+// inputs it signed less the change returning to it, which counts the fee.
+// Each summary carries that value, so that a maximum value applies to the
+// whole transaction rather than to each of its outputs. A PSBT paying only
+// the wallet back is summarized as paying the wallet address.
+func psbtSummaries(packet *chains.Psbt, wallet *kmsWallet, signatures []chains.PsbtSignature) ([]*txSummary, *big.Int, error) {
+	network, err := chains.GetBitcoinNetwork(wallet.Network)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pubKey, err := wallet.publicKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value := new(big.Int)
+	for _, signature := range signatures {
+		prevOut, err := packet.PrevOut(signature.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+		value.Add(value, big.NewInt(prevOut.Value))
+	}
+
+	var summaries []*txSummary
+	for _, out := range packet.UnsignedTx.Outputs {
+		if chains.BitcoinOwnedScript(out.Script, pubKey) {
+			value.Sub(value, big.NewInt(out.Value))
+			continue
+		}
+		summaries = append(summaries, &txSummary{
+			To:      chains.BitcoinScriptAddress(out.Script, network),
+			ChainID: network.Name,
+		})
+	}
+	if len(summaries) == 0 {
+		summaries = append(summaries, &txSummary{To: wallet.Address, ChainID: network.Name})
+	}
+
+	// Inputs of other signers may fund the change.
+	if value.Sign() < 0 {
+		value.SetInt64(0)
+	}
+	for _, summary := range summaries {
+		summary.Value = value
+	}
+
+	return summaries, value, nil
+}
+
+const (
+	pathSignPsbtHelpSynopsis    = `Signs the inputs of a bitcoin PSBT owned by a wallet.`
+	pathSignPsbtHelpDescription = `
+This path lets you sign a base64 encoded PSBT (BIP-174) with a bitcoin wallet.
+Every input spending a P2PKH, P2WPKH, P2SH-P2WPKH or P2TR key path output of the
+wallet is signed, with ECDSA for the legacy and SegWit v0 inputs and with BIP-340
+Schnorr for the Taproot inputs. The updated PSBT is returned with the indexes of
+the signed inputs, and is left for the finalizer.
+
+The outputs paying outside the wallet are checked against the wallet policy,
+with the network name as the chain id and the value the wallet spends in
+satoshis: its signed inputs less its change, which includes the fee. A PSBT
+paying only the wallet back is checked as paying the wallet address.
+`
+)
@@ -0,0 +1,208 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	b64 "encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPsbt(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	privateKeyHex := "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d"
+	privateKeyBytes, _ := hex.DecodeString(privateKeyHex)
+	pubKey := secp256k1.PrivKeyFromBytes(privateKeyBytes).PubKey()
+
+	resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+		"username":    username,
+		"chainName":   "bitcoin",
+		"privateKey":  privateKeyHex,
+		"network":     "regtest",
+		"addressType": "p2tr",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+	require.True(t, strings.HasPrefix(walletAddress, "bcrt1p"))
+
+	t.Run("Test Wallet Addresses", func(t *testing.T) {
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      walletStoragePath,
+			Data:      map[string]interface{}{"username": username, "address": walletAddress},
+			Storage:   reqStorage,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "regtest", resp.Data["network"])
+		require.Equal(t, "p2tr", resp.Data["address_type"])
+
+		addresses := resp.Data["addresses"].(map[string]string)
+		require.Equal(t, walletAddress, addresses["p2tr"])
+		require.True(t, strings.HasPrefix(addresses["p2wpkh"], "bcrt1q"))
+
+		_, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "icon",
+			"network":   "regtest",
+		})
+		require.ErrorContains(t, err, "address options are not supported for icon")
+
+		_, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":    username,
+			"chainName":   "bitcoin",
+			"addressType": "p2sh",
+		})
+		require.ErrorContains(t, err, "unknown bitcoin address type")
+	})
+
+	outputKey, err := chains.TaprootOutputKey(pubKey, nil)
+	require.NoError(t, err)
+	walletScripts := [][]byte{
+		chains.P2WPKHScript(pubKey.SerializeCompressed()),
+		chains.P2TRScript(chains.XOnlyPublicKey(outputKey)),
+	}
+	external := chains.P2WPKHScript(bytes.Repeat([]byte{0x02}, 33))
+
+	// The PSBT spends both outputs of the wallet, paying 30000 outside and the change back.
+	psbt := testPsbt([]*chains.BitcoinTxOut{
+		{Value: 40000, Script: walletScripts[0]},
+		{Value: 20000, Script: walletScripts[1]},
+	}, []*chains.BitcoinTxOut{
+		{Value: 30000, Script: external},
+		{Value: 29000, Script: walletScripts[1]},
+	})
+	signData := map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"psbt":     psbt,
+	}
+
+	t.Run("Test Policy", func(t *testing.T) {
+		_, err := testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"maxValue": "20000",
+		})
+		require.NoError(t, err)
+
+		_, err = testSignPsbt(t, b, reqStorage, signData)
+		require.ErrorContains(t, err, "value 31000 exceeds maximum 20000")
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+			"username":        username,
+			"address":         walletAddress,
+			"maxValue":        "50000",
+			"allowedChainIds": "mainnet",
+		})
+		require.NoError(t, err)
+
+		_, err = testSignPsbt(t, b, reqStorage, signData)
+		require.ErrorContains(t, err, "chain id regtest is not allowed")
+
+		// A PSBT paying everything but the fee back to the wallet spends the fee.
+		_, err = testSignPsbt(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"psbt": testPsbt([]*chains.BitcoinTxOut{{Value: 100000, Script: walletScripts[0]}}, []*chains.BitcoinTxOut{
+				{Value: 1000, Script: walletScripts[1]},
+			}),
+		})
+		require.ErrorContains(t, err, "value 99000 exceeds maximum 50000")
+
+		_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Test Sign", func(t *testing.T) {
+		resp, err := testSignPsbt(t, b, reqStorage, signData)
+		require.NoError(t, err)
+		require.Equal(t, []int{0, 1}, resp.Data["signed_inputs"])
+
+		signed, err := chains.ParsePsbtBase64(resp.Data["psbt"].(string))
+		require.NoError(t, err)
+		require.NotEqual(t, psbt, signed.Base64())
+
+		resp, err = testLedgerRequest(t, b, reqStorage, logical.ListOperation, "wallet/ledger/"+username+"/"+walletAddress+"/", nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", "2"}, resp.Data["keys"])
+		require.Equal(t, ledgerKindPsbt, resp.Data["key_info"].(map[string]interface{})["1"].(map[string]interface{})["kind"])
+	})
+
+	t.Run("Test Not Owned", func(t *testing.T) {
+		_, err := testSignPsbt(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"psbt": testPsbt([]*chains.BitcoinTxOut{{Value: 40000, Script: external}}, []*chains.BitcoinTxOut{
+				{Value: 30000, Script: external},
+			}),
+		})
+		require.ErrorContains(t, err, "no input of the psbt is owned")
+
+		_, err = testSignPsbt(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"psbt":     "cHNidP8=",
+		})
+		require.ErrorContains(t, err, "invalid psbt")
+	})
+}
+
+// testPsbt returns a base64 PSBT spending prevOuts, with their witness UTXOs.
+func testPsbt(prevOuts []*chains.BitcoinTxOut, outputs []*chains.BitcoinTxOut) string {
+	tx := &chains.BitcoinTx{Version: 2, Outputs: outputs}
+	for i := range prevOuts {
+		tx.Inputs = append(tx.Inputs, &chains.BitcoinTxIn{PrevHash: [32]byte{byte(i + 1)}, Sequence: 0xffffffff})
+	}
+
+	var buf bytes.Buffer
+	writePair := func(key []byte, value []byte) {
+		buf.WriteByte(byte(len(key)))
+		buf.Write(key)
+		buf.WriteByte(byte(len(value)))
+		buf.Write(value)
+	}
+
+	buf.WriteString("psbt\xff")
+	writePair([]byte{0x00}, tx.SerializeNoWitness())
+	buf.WriteByte(0x00)
+	for _, prevOut := range prevOuts {
+		utxo := binary.LittleEndian.AppendUint64(nil, uint64(prevOut.Value))
+		utxo = append(utxo, byte(len(prevOut.Script)))
+		writePair([]byte{0x01}, append(utxo, prevOut.Script...))
+		buf.WriteByte(0x00)
+	}
+	for range outputs {
+		buf.WriteByte(0x00)
+	}
+	return b64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func testSignPsbt(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        "wallet/sign/psbt",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -0,0 +1,105 @@
+package kms
+
+import (
+	"crypto/ed25519"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type solanaTxSigner struct {
+	message     []byte
+	parsed      *chains.SolanaMessage
+	signerIndex int
+	transfers   []chains.SolanaTransfer
+}
+
+// newSolanaTxSigner reads the base64 serialized legacy or v0 message from
+// the tx. The wallet must be one of the signers the message requires.
+func newSolanaTxSigner(wallet *kmsWallet, tx map[string]interface{}) (*solanaTxSigner, error) {
+	message, err := txBase64(tx, "message")
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, fmt.Errorf("missing message in tx")
+	}
+
+	parsed, err := chains.ParseSolanaMessage(message)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := hex.DecodeString(wallet.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decode public key: %w", err)
+	}
+	signerIndex := parsed.SignerIndex(ed25519.PublicKey(pubKey))
+	if signerIndex < 0 {
+		return nil, fmt.Errorf("wallet %v is not a signer of the message", wallet.Address)
+	}
+
+	transfers, err := parsed.Transfers()
+	if err != nil {
+		return nil, err
+	}
+
+	return &solanaTxSigner{
+		message:     message,
+		parsed:      parsed,
+		signerIndex: signerIndex,
+		transfers:   transfers,
+	}, nil
+}
+
+// digest returns the message itself, which ed25519 signs.
+func (s *solanaTxSigner) digest() []byte {
+	return s.message
+}
+
+// summary reports the programs the message invokes as the method, and the
+// recipients and the sum of the lamports of its System program transfers.
+// Recipients loaded from address lookup tables are named by their table
+// and index, so that they never match an allowed destination. Any other
+// instruction, of another program or of the System program, may move funds
+// the transfers do not show, so it makes the summary partial.
+func (s *solanaTxSigner) summary() *txSummary {
+	summary := &txSummary{
+		Method: strings.Join(s.parsed.ProgramIDs(), ","),
+		Value:  new(big.Int),
+		// Transfers reads one transfer from each instruction it decodes.
+		Partial: len(s.transfers) < len(s.parsed.Instructions),
+	}
+
+	var recipients []string
+	for _, transfer := range s.transfers {
+		if !containsFold(recipients, transfer.To) {
+			recipients = append(recipients, transfer.To)
+		}
+		summary.Value.Add(summary.Value, new(big.Int).SetUint64(transfer.Lamports))
+	}
+	summary.To = strings.Join(recipients, ",")
+
+	return summary
+}
+
+// signed returns the base58 signature, and the transaction with the
+// signature of the wallet in its place, the others left empty.
+func (s *solanaTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	signatures := make([][]byte, s.parsed.NumRequiredSignatures)
+	for i := range signatures {
+		signatures[i] = make([]byte, ed25519.SignatureSize)
+	}
+	signatures[s.signerIndex] = signature
+
+	return map[string]interface{}{
+		"signature":    base58.Encode(signature),
+		"signer_index": s.signerIndex,
+		"transaction":  b64.StdEncoding.EncodeToString(chains.SolanaTransaction(s.message, signatures)),
+	}, nil
+}
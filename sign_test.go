@@ -1,14 +1,19 @@
 package kms
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	b64 "encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"testing"
 
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/sha3"
@@ -119,6 +124,17 @@ func TestSign(t *testing.T) {
 		assert.NotNil(t, sign)
 
 		t.Logf("signature : %v", sign.(string))
+
+		delete(reqData, "chainName")
+		resp, err = testSignCreate(t, b, reqStorage, reqData)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sign, resp.Data["signature"])
+
+		reqData["chainName"] = "ether"
+		_, err = testSignCreate(t, b, reqStorage, reqData)
+
+		assert.ErrorContains(t, err, "chainName mismatch")
 	})
 }
 
@@ -140,3 +156,732 @@ func testSignCreate(t *testing.T, b logical.Backend, s logical.Storage, d map[st
 	}
 	return resp, nil
 }
+
+// TestSignAergoTx mocks the signing of structured Aergo transactions for kms.
+func TestSignAergoTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "aergo",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"nonce":       1,
+			"recipient":   "aergo.system",
+			"amount":      "1000000000000000000",
+			"payload":     "",
+			"gasLimit":    100000,
+			"type":        "GOVERNANCE",
+			"chainIdHash": "BNVSYKKqSR78hTSrxkaroFK2H5BqvrAPHE3JmrgHcaRQ",
+		},
+	})
+	require.NoError(t, err)
+
+	signedTx := resp.Data["tx"].(map[string]interface{})
+	require.Equal(t, walletAddress, signedTx["account"])
+	require.NotEmpty(t, signedTx["sign"])
+	require.NotEmpty(t, resp.Data["tx_hash"])
+
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"address":   walletAddress,
+		"chainName": "aergo",
+		"msgHash":   "0000000000000000000000000000000000000000000000000000000000000000",
+		"signature": resp.Data["signature"],
+	})
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["valid"])
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"account":     "AmN5kDEYAxUzFvjX6541AVrPkzeg2H4Qxc79ssBWUpqNLbur9M36",
+			"chainIdHash": "BNVSYKKqSR78hTSrxkaroFK2H5BqvrAPHE3JmrgHcaRQ",
+		},
+	})
+	require.ErrorContains(t, err, "does not match wallet")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"msgHash":  "zz00000000000000000000000000000000000000000000000000000000000000",
+	})
+	require.ErrorContains(t, err, "invalid msgHash")
+}
+
+// TestSignIconTx mocks the signing of ICON transaction objects for kms.
+func TestSignIconTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+		"username":   username,
+		"chainName":  "icon",
+		"privateKey": "1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"version":   "0x3",
+			"from":      walletAddress,
+			"to":        "cxcb952e97e554800a1da099e5102079ceda03b277",
+			"value":     "0x8ac7230489e80000",
+			"stepLimit": "0x11b340",
+			"timestamp": "0x5fdaf54c5ed34",
+			"nid":       "0x7",
+			"nonce":     "0x1",
+		},
+	})
+	require.NoError(t, err)
+
+	expectedSignature := "r+bGnNA1RBcw5SVAinoSDo4plG8/X5HzZ6cJxlfTv/REyg0bgErn/oZIj43OhN/Xquwx0oNwQ27UBVWkT4ByXQE="
+	require.Equal(t, expectedSignature, resp.Data["signature"])
+
+	signedTx := resp.Data["tx"].(map[string]interface{})
+	require.Equal(t, expectedSignature, signedTx["signature"])
+	require.NotEmpty(t, resp.Data["tx_hash"])
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"to": "cxcb952e97e554800a1da099e5102079ceda03b277",
+		},
+	})
+	require.ErrorContains(t, err, "missing nid")
+}
+
+// TestSignEtherTx mocks the signing of Ethereum transactions for kms.
+func TestSignEtherTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+	tx := map[string]interface{}{
+		"chainId":              "0xaa36a7",
+		"nonce":                "0x0",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"maxFeePerGas":         "0x77359400",
+		"gas":                  "0x5208",
+		"to":                   "0x3535353535353535353535353535353535353535",
+		"value":                "0xde0b6b3a7640000",
+		"accessList": []interface{}{
+			map[string]interface{}{
+				"address":     "0x3535353535353535353535353535353535353535",
+				"storageKeys": []interface{}{"0x0000000000000000000000000000000000000000000000000000000000000001"},
+			},
+		},
+	}
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0x02", resp.Data["raw_tx"].(string)[:4])
+	require.Len(t, resp.Data["tx_hash"], 66)
+
+	etherTx, err := parseEtherTx(tx)
+	require.NoError(t, err)
+
+	hash, err := etherTx.SigningHash()
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(resp.Data["signature"].(string))
+	require.NoError(t, err)
+
+	pubKey, err := chains.RecoverCompact(hash, sigBytes)
+	require.NoError(t, err)
+
+	address, err := chains.PublicKeyAddress(chains.ETHER, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, walletAddress, address)
+
+	delete(tx, "chainId")
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.ErrorContains(t, err, "missing chainId")
+
+	// A legacy tx is not signed without the replay protection of EIP-155.
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"nonce":    "0x0",
+			"gasPrice": "0x3b9aca00",
+			"gas":      "0x5208",
+			"to":       "0x3535353535353535353535353535353535353535",
+		},
+	})
+	require.ErrorContains(t, err, "missing chainId")
+}
+
+// TestSignCosmosTx mocks the signing of Cosmos SDK sign docs for kms.
+func TestSignCosmosTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "cosmos",
+		"hrp":       "osmo",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+	require.Equal(t, "osmo1", walletAddress[:5])
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"authInfoBytes": "CgA=",
+			"chainId":       "osmosis-1",
+			"accountNumber": 42,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, chains.CosmosSignModeDirect, resp.Data["sign_mode"])
+
+	signBytes, err := b64.StdEncoding.DecodeString(resp.Data["sign_bytes"].(string))
+	require.NoError(t, err)
+
+	sigBytes, err := b64.StdEncoding.DecodeString(resp.Data["signature"].(string))
+	require.NoError(t, err)
+	require.Len(t, sigBytes, 64)
+
+	pubKeyBytes, err := b64.StdEncoding.DecodeString(resp.Data["public_key"].(string))
+	require.NoError(t, err)
+
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"publicKey": hex.EncodeToString(pubKeyBytes),
+		"chainName": "cosmos",
+		"msgHash":   hex.EncodeToString(chains.CosmosSignHash(signBytes)),
+		"signature": resp.Data["signature"],
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["valid"])
+
+	signDoc := map[string]interface{}{
+		"account_number": "42",
+		"chain_id":       "osmosis-1",
+		"fee":            map[string]interface{}{"gas": "200000", "amount": []interface{}{}},
+		"memo":           "",
+		"msgs": []interface{}{map[string]interface{}{
+			"type":  "cosmos-sdk/MsgSend",
+			"value": map[string]interface{}{"to_address": "osmo1to", "amount": []interface{}{map[string]interface{}{"denom": "uosmo", "amount": "10"}}},
+		}},
+		"sequence": "7",
+	}
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"allowedTo": "osmo1other",
+	})
+	require.NoError(t, err)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       map[string]interface{}{"signDoc": signDoc},
+	})
+	require.ErrorContains(t, err, "is not allowed")
+
+	// A send to an allowed recipient does not carry a delegation along.
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"allowedTo": "osmo1to",
+	})
+	require.NoError(t, err)
+
+	mixedDoc := map[string]interface{}{}
+	for k, v := range signDoc {
+		mixedDoc[k] = v
+	}
+	mixedDoc["msgs"] = append([]interface{}{map[string]interface{}{
+		"type":  "cosmos-sdk/MsgDelegate",
+		"value": map[string]interface{}{"validator_address": "osmovaloper1v", "amount": map[string]interface{}{"denom": "uosmo", "amount": "1000000"}},
+	}}, signDoc["msgs"].([]interface{})...)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       map[string]interface{}{"signDoc": mixedDoc},
+	})
+	require.ErrorContains(t, err, "cannot be checked against the policy")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       map[string]interface{}{"signDoc": signDoc},
+	})
+	require.NoError(t, err)
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+	})
+	require.NoError(t, err)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       map[string]interface{}{"signDoc": signDoc},
+	})
+	require.NoError(t, err)
+	require.Equal(t, chains.CosmosSignModeAminoJSON, resp.Data["sign_mode"])
+
+	_, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "icon",
+		"hrp":       "osmo",
+	})
+	require.ErrorContains(t, err, "address options are not supported for icon")
+}
+
+// TestSignTronTx mocks the signing of TRON transactions for kms.
+func TestSignTronTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+		"username":   username,
+		"chainName":  "tron",
+		"privateKey": "4646464646464646464646464646464646464646464646464646464646464646",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+	expectedAddress, err := chains.TronHexToAddress("419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f")
+	require.NoError(t, err)
+	require.Equal(t, expectedAddress, walletAddress)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      walletStoragePath,
+		Data:      map[string]interface{}{"username": username, "address": walletAddress},
+		Storage:   reqStorage,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f", resp.Data["hex_address"])
+
+	// A transfer of 1 TRX to TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t.
+	txID := "cb4e28f61c2cea0e44bb9fba89cc867e1c58bb187ade07f636a6d8bd539b6cf5"
+	tx := map[string]interface{}{
+		"txID": txID,
+		"raw_data_hex": "0a028f4e22081b2e6a2c951d246740e0a499ffbc315a67080112630a2d747970652e676f6f676c65617069732e636f6d2f" +
+			"70726f746f636f6c2e5472616e73666572436f6e747261637412320a15419d8a62f656a8d1615c1294fd71e9cfb3e4855a4f" +
+			"121541a614f803b6fd780986a42c78ec9c7f77e6ded13c18c0843d7080d095ffbc31",
+	}
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":       username,
+		"address":        walletAddress,
+		"maxValue":       "500000",
+		"allowedMethods": "TransferContract",
+	})
+	require.NoError(t, err)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.ErrorContains(t, err, "value 1000000 exceeds maximum 500000")
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"maxValue": "500000",
+	})
+	require.NoError(t, err)
+
+	// An AccountPermissionUpdateContract of the wallet moves no TRX, but may hand the account over.
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"raw_data_hex": "0a028f4e22081b2e6a2c951d246740e0a499ffbc315a64082e12600a3c747970652e676f6f676c65617069732e636f6d2f" +
+				"70726f746f636f6c2e4163636f756e745065726d697373696f6e557064617465436f6e747261637412200a15419d8a62f656a8d1" +
+				"615c1294fd71e9cfb3e4855a4f12071a056f776e65727080d095ffbc31",
+		},
+	})
+	require.ErrorContains(t, err, "cannot be checked against the policy")
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+	})
+	require.NoError(t, err)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.NoError(t, err)
+	require.Equal(t, txID, resp.Data["tx_hash"])
+
+	signedTx := resp.Data["tx"].(map[string]interface{})
+	signature := signedTx["signature"].([]string)[0]
+	require.Len(t, signature, 130)
+	require.Contains(t, []string{"1b", "1c"}, signature[128:])
+
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"address":   walletAddress,
+		"chainName": "tron",
+		"msgHash":   txID,
+		"signature": resp.Data["signature"],
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["valid"])
+
+	tx["txID"] = "00" + txID[2:]
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.ErrorContains(t, err, "does not match raw_data_hex")
+
+	resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "tron",
+	})
+	require.NoError(t, err)
+
+	delete(tx, "txID")
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  resp.Data["address"],
+		"tx":       tx,
+	})
+	require.ErrorContains(t, err, "does not match wallet")
+
+	// Tron signs the SHA-256 of the raw data, which txSerialized would not be hashed with.
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username":     username,
+		"address":      walletAddress,
+		"txSerialized": tx["raw_data_hex"],
+	})
+	require.ErrorContains(t, err, "txSerialized signing is not supported for tron")
+}
+
+// TestSignKaiaTx mocks the signing of Kaia transactions by their sender and fee payer for kms.
+func TestSignKaiaTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	// The key of the TxTypeValueTransfer example of the Klaytn documentation.
+	resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+		"username":   username,
+		"chainName":  "kaia",
+		"privateKey": "45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+	})
+	require.NoError(t, err)
+
+	senderAddress := resp.Data["address"].(string)
+	require.Equal(t, "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b", senderAddress)
+
+	tx := map[string]interface{}{
+		"chainId":  1,
+		"nonce":    1234,
+		"gasPrice": "0x19",
+		"gas":      "0xf4240",
+		"to":       "0x7b65B75d204aBed71587c9E519a89277766EE1d0",
+		"value":    "0xa",
+	}
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  senderAddress,
+		"tx":       tx,
+	})
+	require.NoError(t, err)
+	require.Equal(t, chains.KaiaValueTransferTxType, resp.Data["tx_type"])
+	require.Equal(t, true, resp.Data["complete"])
+	require.Equal(t, "0x08f87a8204d219830f4240947b65b75d204abed71587c9e519a89277766ee1d00a94a94f5374fce5edbc8e2a8697c15331677e6ebf0bf845f84325a0f3d0cd43661cabf53425535817c5058c27781f478cb5459874feaa462ed3a29aa06748abe186269ff10b8100a4b7d7fea274b53ea2905acbf498dc8b5ab1bf4fbc", resp.Data["raw_tx"])
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  senderAddress,
+		"tx":       map[string]interface{}{"rawTx": resp.Data["raw_tx"]},
+	})
+	require.ErrorContains(t, err, "is not fee-delegated")
+
+	resp, err = testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "kaia",
+	})
+	require.NoError(t, err)
+	feePayerAddress := resp.Data["address"].(string)
+
+	// The sender signs a fee-delegated token transfer, leaving the fee payer to sign it.
+	tx["input"] = "0xa9059cbb"
+	tx["feePayer"] = feePayerAddress
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  senderAddress,
+		"tx":       tx,
+	})
+	require.NoError(t, err)
+	require.Equal(t, chains.KaiaFeeDelegatedSmartContractExecutionTxType, resp.Data["tx_type"])
+	require.Equal(t, "sender", resp.Data["role"])
+	require.Equal(t, false, resp.Data["complete"])
+	senderRawTx := resp.Data["raw_tx"]
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":       username,
+		"address":        feePayerAddress,
+		"allowedMethods": "0x095ea7b3",
+	})
+	require.NoError(t, err)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  feePayerAddress,
+		"tx":       map[string]interface{}{"rawTx": senderRawTx},
+	})
+	require.ErrorContains(t, err, "is not allowed")
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+		"username": username,
+		"address":  feePayerAddress,
+	})
+	require.NoError(t, err)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  feePayerAddress,
+		"tx":       map[string]interface{}{"rawTx": senderRawTx},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "feePayer", resp.Data["role"])
+	require.Equal(t, feePayerAddress, resp.Data["account"])
+	require.Equal(t, true, resp.Data["complete"])
+
+	rawTx, err := hex.DecodeString(resp.Data["raw_tx"].(string)[2:])
+	require.NoError(t, err)
+	kaiaTx, err := chains.ParseKaiaRawTx(rawTx)
+	require.NoError(t, err)
+	require.Len(t, kaiaTx.Signatures, 1)
+	require.Len(t, kaiaTx.FeePayerSignatures, 1)
+	require.Equal(t, "0x"+hex.EncodeToString(chains.Keccak256(rawTx)), resp.Data["tx_hash"])
+
+	feePayerHash, err := kaiaTx.FeePayerSigningHash()
+	require.NoError(t, err)
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"address":   feePayerAddress,
+		"chainName": "kaia",
+		"msgHash":   hex.EncodeToString(feePayerHash),
+		"signature": resp.Data["signature"],
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["valid"])
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  feePayerAddress,
+		"tx":       map[string]interface{}{"rawTx": senderRawTx, "chainId": 1001},
+	})
+	require.ErrorContains(t, err, "does not match the signatures")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  senderAddress,
+		"tx":       map[string]interface{}{"type": 8, "to": tx["to"], "input": "0x00", "chainId": 1},
+	})
+	require.ErrorContains(t, err, "input not supported")
+
+	policy := &kmsPolicy{AllowedMethods: []string{"0x095ea7b3"}}
+	for _, c := range []struct {
+		txType int
+		input  string
+		denied string
+	}{
+		{chains.KaiaValueTransferMemoTxType, "0x01", "method memo is not allowed"},
+		{chains.KaiaSmartContractExecutionTxType, "0xa905", "cannot be checked against allowedMethods"},
+	} {
+		signer, err := newKaiaTxSigner(&kmsWallet{Address: senderAddress}, defaultConfig(), map[string]interface{}{
+			"type": c.txType, "to": tx["to"], "input": c.input, "chainId": 1,
+		})
+		require.NoError(t, err)
+		require.ErrorContains(t, policy.checkTx(signer.summary()), c.denied)
+	}
+}
+
+// TestSignSolanaTx mocks the signing of Solana transaction messages for kms.
+func TestSignSolanaTx(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	// The seed of test 1 of RFC 8032.
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	pubKey := privateKey.Public().(ed25519.PublicKey)
+
+	resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+		"username":   username,
+		"chainName":  "solana",
+		"privateKey": hex.EncodeToString(seed),
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+	require.Equal(t, base58.Encode(pubKey), walletAddress)
+
+	// Solana wallets export the seed and the public key in base58.
+	otherBackend, otherStorage := getTestBackend(t)
+	resp, err = testWalletImport(t, otherBackend, otherStorage, map[string]interface{}{
+		"username":   username,
+		"chainName":  "solana",
+		"privateKey": base58.Encode(privateKey),
+	})
+	require.NoError(t, err)
+	require.Equal(t, walletAddress, resp.Data["address"])
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      walletStoragePath,
+		Data:      map[string]interface{}{"username": username, "address": walletAddress},
+		Storage:   reqStorage,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ed25519", resp.Data["key_algorithm"])
+
+	recipient := bytes.Repeat([]byte{0x02}, 32)
+	message := testSolanaTransfer(pubKey, recipient, 5000)
+	tx := map[string]interface{}{
+		"message": b64.StdEncoding.EncodeToString(message),
+	}
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"maxValue":  "1000",
+		"allowedTo": base58.Encode(recipient),
+	})
+	require.NoError(t, err)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.ErrorContains(t, err, "value 5000 exceeds maximum 1000")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"message": b64.StdEncoding.EncodeToString(testSolanaTransfer(pubKey, recipient, 500)),
+		},
+	})
+	require.NoError(t, err)
+
+	// The allowed transfer does not carry along an instruction of another program.
+	tokenProgram := bytes.Repeat([]byte{0xcc}, 32)
+	mixed := []byte{1, 0, 2, 4}
+	for _, account := range [][]byte{pubKey, recipient, make([]byte, 32), tokenProgram, bytes.Repeat([]byte{0xbb}, 32)} {
+		mixed = append(mixed, account...)
+	}
+	data := binary.LittleEndian.AppendUint32(nil, 2)
+	data = binary.LittleEndian.AppendUint64(data, 500)
+	mixed = append(mixed, 2, 2, 2, 0, 1, byte(len(data)))
+	mixed = append(mixed, data...)
+	mixed = append(mixed, 3, 2, 0, 1, 1, 3)
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"message": b64.StdEncoding.EncodeToString(mixed),
+		},
+	})
+	require.ErrorContains(t, err, "cannot be checked against the policy")
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.DeleteOperation, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+	})
+	require.NoError(t, err)
+
+	resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx":       tx,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, resp.Data["signer_index"])
+
+	signature := base58.Decode(resp.Data["signature"].(string))
+	require.True(t, ed25519.Verify(pubKey, message, signature))
+
+	transaction, err := b64.StdEncoding.DecodeString(resp.Data["transaction"].(string))
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte{0x01}, signature...), message...), transaction)
+
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"address":   walletAddress,
+		"chainName": "solana",
+		"msgHash":   hex.EncodeToString(message),
+		"signature": resp.Data["signature"],
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["valid"])
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"message": b64.StdEncoding.EncodeToString(testSolanaTransfer(recipient, pubKey, 5000)),
+		},
+	})
+	require.ErrorContains(t, err, "is not a signer of the message")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username":     username,
+		"address":      walletAddress,
+		"txSerialized": hex.EncodeToString(message),
+	})
+	require.ErrorContains(t, err, "txSerialized signing is not supported for solana")
+
+	_, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"msgHash":  hex.EncodeToString(message),
+	})
+	require.ErrorContains(t, err, "msgHash signing is not supported for solana")
+}
+
+// testSolanaTransfer returns the legacy message of a System program transfer paid by from.
+func testSolanaTransfer(from []byte, to []byte, lamports uint64) []byte {
+	message := []byte{1, 0, 1, 3}
+	message = append(message, from...)
+	message = append(message, to...)
+	message = append(message, make([]byte, 32)...)
+	message = append(message, bytes.Repeat([]byte{0xbb}, 32)...)
+
+	data := binary.LittleEndian.AppendUint32(nil, 2)
+	data = binary.LittleEndian.AppendUint64(data, lamports)
+	message = append(message, 1, 2, 2, 0, 1, byte(len(data)))
+	return append(message, data...)
+}
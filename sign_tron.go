@@ -0,0 +1,108 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+type tronTxSigner struct {
+	tx       map[string]interface{}
+	txID     []byte
+	contract *chains.TronContract
+}
+
+// newTronTxSigner reads the transaction from the raw_data_hex field of a
+// TronWeb transaction object, whose txID is checked when present.
+func newTronTxSigner(wallet *kmsWallet, tx map[string]interface{}) (*tronTxSigner, error) {
+	rawDataHex, err := txString(tx, "raw_data_hex")
+	if err != nil {
+		return nil, err
+	}
+	if rawDataHex == "" {
+		return nil, fmt.Errorf("missing raw_data_hex in tx")
+	}
+	rawData, err := hex.DecodeString(rawDataHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw_data_hex in tx, expected hex: %w", err)
+	}
+
+	contract, err := chains.ParseTronContract(rawData)
+	if err != nil {
+		return nil, err
+	}
+	if contract.Owner == nil {
+		return nil, fmt.Errorf("missing owner_address in the tron contract")
+	}
+	owner, err := chains.EncodeTronAddress(contract.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if owner != wallet.Address {
+		return nil, fmt.Errorf("tx owner %v does not match wallet %v", owner, wallet.Address)
+	}
+
+	txID := chains.TronTxID(rawData)
+	if expected, err := txString(tx, "txID"); err != nil {
+		return nil, err
+	} else if expected != "" {
+		if expectedBytes, err := hex.DecodeString(expected); err != nil || !bytes.Equal(expectedBytes, txID) {
+			return nil, fmt.Errorf("txID %v does not match raw_data_hex", expected)
+		}
+	}
+
+	signed := make(map[string]interface{}, len(tx)+2)
+	for k, v := range tx {
+		signed[k] = v
+	}
+
+	return &tronTxSigner{
+		tx:       signed,
+		txID:     txID,
+		contract: contract,
+	}, nil
+}
+
+func (s *tronTxSigner) digest() []byte {
+	return s.txID
+}
+
+// summary reports the called method selector of smart contract triggers,
+// and the contract type name of the other transactions. Only TRX transfers
+// and triggers are read in full: the other contracts, such as a TRC-10
+// transfer or a permission update, make the summary partial.
+func (s *tronTxSigner) summary() *txSummary {
+	summary := &txSummary{
+		Value:   s.contract.Amount,
+		Partial: s.contract.Type != chains.TronTransferContract && s.contract.Type != chains.TronTriggerSmartContract,
+		Data:    len(s.contract.Data) > 0,
+	}
+	if s.contract.To != nil {
+		summary.To, _ = chains.EncodeTronAddress(s.contract.To)
+	}
+	if s.contract.Type == chains.TronTriggerSmartContract {
+		if len(s.contract.Data) >= 4 {
+			summary.Method = "0x" + hex.EncodeToString(s.contract.Data[:4])
+		} else if len(s.contract.Data) == 0 {
+			summary.Method = chains.TronContractTypes[s.contract.Type]
+		}
+	} else if name, ok := chains.TronContractTypes[s.contract.Type]; ok {
+		summary.Method = name
+	} else {
+		summary.Method = fmt.Sprint(s.contract.Type)
+	}
+	return summary
+}
+
+func (s *tronTxSigner) signed(signature []byte) (map[string]interface{}, error) {
+	txID := hex.EncodeToString(s.txID)
+	s.tx["txID"] = txID
+	s.tx["signature"] = []string{hex.EncodeToString(signature)}
+
+	return map[string]interface{}{
+		"tx":      s.tx,
+		"tx_hash": txID,
+	}, nil
+}
@@ -0,0 +1,111 @@
+package kms
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+// txSigner computes the digest of a structured transaction and
+// assembles the signed transaction from the chain signature.
+type txSigner interface {
+	// digest returns the 32-byte hash to sign, or the message itself for
+	// the chains of ed25519 keys.
+	digest() []byte
+	// signed returns the response data of the transaction signed with
+	// the native signature of the chain.
+	signed(signature []byte) (map[string]interface{}, error)
+	// summary returns the fields signing policies are checked against.
+	summary() *txSummary
+}
+
+func newTxSigner(chainName chains.ChainName, wallet *kmsWallet, config *kmsConfig, tx map[string]interface{}) (txSigner, error) {
+	switch chainName {
+	case chains.AERGO:
+		return newAergoTxSigner(wallet, tx)
+	case chains.ICON:
+		return newIconTxSigner(wallet, config, tx)
+	case chains.ETHER:
+		return newEtherTxSigner(wallet, config, tx)
+	case chains.COSMOS:
+		return newCosmosTxSigner(wallet, tx)
+	case chains.TRON:
+		return newTronTxSigner(wallet, tx)
+	case chains.SOLANA:
+		return newSolanaTxSigner(wallet, tx)
+	case chains.KAIA:
+		return newKaiaTxSigner(wallet, config, tx)
+	}
+	return nil, fmt.Errorf("structured tx not supported for %v", chainName)
+}
+
+// txString returns the string field of the transaction, or "" when absent.
+func txString(tx map[string]interface{}, key string) (string, error) {
+	switch v := tx[key].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid %v in tx: %v", key, tx[key])
+}
+
+// txBigInt returns the numeric field of the transaction, given as a JSON
+// number or as a decimal or 0x-prefixed hex string, or nil when absent.
+func txBigInt(tx map[string]interface{}, key string) (*big.Int, error) {
+	var s string
+	switch v := tx[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("invalid %v in tx: %v", key, v)
+	}
+
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") {
+		n, ok = n.SetString(s[2:], 16)
+	} else {
+		n, ok = n.SetString(s, 10)
+	}
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %v in tx: %v", key, s)
+	}
+	return n, nil
+}
+
+// txUint64 returns the numeric field of the transaction as uint64, or 0 when absent.
+func txUint64(tx map[string]interface{}, key string) (uint64, error) {
+	n, err := txBigInt(tx, key)
+	if err != nil || n == nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("invalid %v in tx: %v", key, n)
+	}
+	return n.Uint64(), nil
+}
+
+// txValue returns the value of the transaction, or 0 when absent, as the
+// chains encode it. A nil summary Value stands for a value that cannot be read.
+func txValue(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
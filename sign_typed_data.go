@@ -0,0 +1,153 @@
+package kms
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+// ethSignatureVOffset is added to the recovery code of the signatures
+// returned by the Ethereum wallet signing methods.
+const ethSignatureVOffset = 27
+
+func pathSignTypedData(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/sign/typed-data",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+				"typedData": {
+					Type:        framework.TypeString,
+					Description: "EIP-712 typed data JSON with types, primaryType, domain and message",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignTypedData,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignTypedData,
+				},
+			},
+			HelpSynopsis:    pathSignTypedDataHelpSynopsis,
+			HelpDescription: pathSignTypedDataHelpDescription,
+		},
+	}
+}
+
+func (b *kmsBackend) pathSignTypedData(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		username = un.(string)
+	} else {
+		return nil, fmt.Errorf("missing username in sign")
+	}
+
+	var address string
+	if addr, ok := d.GetOk("address"); ok {
+		address = addr.(string)
+	} else {
+		return nil, fmt.Errorf("missing address in sign")
+	}
+
+	typedData, err := chains.ParseTypedData([]byte(d.Get("typedData").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newSignSession(b, config)
+	wallet, chainName, err := session.wallet(ctx, req, username, address, chains.ETHER)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := session.policy(ctx, req, username, address)
+	if err != nil {
+		return nil, err
+	}
+	if policy.requiresApproval() {
+		return nil, fmt.Errorf("wallet requires %v approvals, which typed data signing does not support", policy.RequiredApprovals)
+	}
+	if err := policy.checkMessage("typed data"); err != nil {
+		return nil, err
+	}
+
+	if chainID, ok := config.networkID(chains.ETHER); ok {
+		if domainChainID, err := txBigInt(typedData.Domain, "chainId"); err != nil {
+			return nil, err
+		} else if domainChainID != nil && (!domainChainID.IsUint64() || domainChainID.Uint64() != chainID) {
+			return nil, fmt.Errorf("typed data chainId %v does not match %v", domainChainID, chainID)
+		}
+	}
+
+	digest, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := session.chain(ctx, req, username, address, wallet, chainName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature counts against the limits, with no value.
+	if err := b.reserveUsage(ctx, req.Storage, signLimitScopes(username, address, chainName), new(big.Int)); err != nil {
+		return nil, err
+	}
+
+	signature, err := chain.SignCompact(digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: err=%v", err)
+	}
+
+	if err := session.record(ctx, req, username, address, &ledgerEntry{
+		ChainName: chainName,
+		Kind:      ledgerKindTypedData,
+		Digest:    hex.EncodeToString(digest),
+		Signature: signature,
+		EntityID:  req.EntityID,
+	}); err != nil {
+		return nil, err
+	}
+
+	if signature, err = walletSignature(chainName, config, signature); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"signature": signature,
+			"digest":    "0x" + hex.EncodeToString(digest),
+		},
+	}, nil
+}
+
+const (
+	pathSignTypedDataHelpSynopsis    = `Signs EIP-712 typed structured data with an Ethereum wallet.`
+	pathSignTypedDataHelpDescription = `
+This path lets you produce eth_signTypedData_v4 signatures, such as permits
+and meta-transactions. Provide the typed data JSON in the typedData field.
+The digest is computed from the domain separator and the message struct hash,
+and the 65-byte signature R||S||V is returned as a hex string with V of 27 or 28.
+`
+)
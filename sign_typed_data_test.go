@@ -0,0 +1,114 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+const testTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "chainId", "type": "uint256"}
+		],
+		"Permit": [
+			{"name": "spender", "type": "address"},
+			{"name": "value", "type": "uint256"},
+			{"name": "tags", "type": "string[]"}
+		]
+	},
+	"primaryType": "Permit",
+	"domain": {"name": "Token", "chainId": 1},
+	"message": {
+		"spender": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+		"value": "1000000000000000000",
+		"tags": ["a", "b"]
+	}
+}`
+
+// TestSignTypedData mocks the EIP-712 signing for kms.
+func TestSignTypedData(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+
+	walletAddress := resp.Data["address"].(string)
+
+	resp, err = testSignTypedData(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"typedData": testTypedData,
+	})
+	require.NoError(t, err)
+
+	signature := resp.Data["signature"].(string)
+	require.Len(t, signature, 2+65*2)
+	require.Contains(t, []string{"1b", "1c"}, signature[len(signature)-2:])
+
+	resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+		"address":   walletAddress,
+		"chainName": "ether",
+		"msgHash":   resp.Data["digest"],
+		"signature": signature,
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["valid"])
+
+	_, err = testConfigRequest(t, b, reqStorage, logical.UpdateOperation, map[string]interface{}{
+		"networkIds": map[string]interface{}{"ether": "5"},
+	})
+	require.NoError(t, err)
+
+	_, err = testSignTypedData(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"typedData": testTypedData,
+	})
+	require.ErrorContains(t, err, "does not match")
+
+	// A permit is a token transfer the policy cannot check.
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"maxValue": "100",
+	})
+	require.NoError(t, err)
+
+	_, err = testConfigRequest(t, b, reqStorage, logical.UpdateOperation, map[string]interface{}{
+		"networkIds": map[string]interface{}{"ether": "1"},
+	})
+	require.NoError(t, err)
+
+	_, err = testSignTypedData(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"address":   walletAddress,
+		"typedData": testTypedData,
+	})
+	require.ErrorContains(t, err, "policy denied: typed data cannot be checked")
+}
+
+func testSignTypedData(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/sign/typed-data",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
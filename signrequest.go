@@ -0,0 +1,427 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	signRequestStoragePath = "signrequest"
+
+	signRequestPending  = "pending"
+	signRequestSigned   = "signed"
+	signRequestRejected = "rejected"
+	signRequestExpired  = "expired"
+	signRequestFailed   = "failed"
+
+	// signRequestRetention is how long a sign request is kept past its
+	// expiry, so the result of a decided request can still be read.
+	signRequestRetention = 24 * time.Hour
+)
+
+// kmsSignRequest is a signing request waiting for the approvals required
+// by the policy of its wallet. The approvers and the quorum are fixed
+// when the request is submitted.
+type kmsSignRequest struct {
+	ID                string                 `json:"id"`
+	Request           *signRequest           `json:"request"`
+	Status            string                 `json:"status"`
+	RequestedBy       string                 `json:"requested_by"`
+	Approvers         []string               `json:"approvers"`
+	RequiredApprovals int                    `json:"required_approvals"`
+	Approvals         []string               `json:"approvals"`
+	Rejections        []string               `json:"rejections"`
+	CreatedAt         time.Time              `json:"created_at"`
+	ExpiresAt         time.Time              `json:"expires_at"`
+	Result            map[string]interface{} `json:"result,omitempty"`
+	Error             string                 `json:"error,omitempty"`
+}
+
+func pathSignRequest(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/sign/request/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address of wallet",
+					Required:    true,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain, defaults to the chain of the wallet",
+					Required:    false,
+				},
+				"txSerialized": {
+					Type:        framework.TypeString,
+					Description: "serialized transaction data",
+					Required:    false,
+				},
+				"msgHash": {
+					Type:        framework.TypeString,
+					Description: "an arbitrary 32-byte message hash to sign, expressed as a hex string",
+					Required:    false,
+				},
+				"tx": {
+					Type:        framework.TypeMap,
+					Description: "structured transaction to sign, with the fields of the chain",
+					Required:    false,
+				},
+				"after": {
+					Type:        framework.TypeString,
+					Description: "list sign request IDs sorted after this value",
+					Required:    false,
+				},
+				"limit": {
+					Type:        framework.TypeInt,
+					Description: "maximum number of sign requests to list",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestList,
+				},
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestCreate,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestCreate,
+				},
+			},
+			HelpSynopsis:    pathSignRequestHelpSynopsis,
+			HelpDescription: pathSignRequestHelpDescription,
+		},
+		{
+			Pattern: "wallet/sign/request/" + framework.GenericNameRegex("id"),
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the sign request",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestRead,
+				},
+			},
+			HelpSynopsis:    pathSignRequestHelpSynopsis,
+			HelpDescription: pathSignRequestHelpDescription,
+		},
+		{
+			Pattern: "wallet/sign/request/" + framework.GenericNameRegex("id") + "/(?P<decision>approve|reject)",
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: "ID of the sign request",
+					Required:    true,
+				},
+				"decision": {
+					Type:        framework.TypeString,
+					Description: "approve or reject",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestDecide,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathSignRequestDecide,
+				},
+			},
+			HelpSynopsis:    pathSignRequestHelpSynopsis,
+			HelpDescription: pathSignRequestHelpDescription,
+		},
+	}
+}
+
+func getSignRequestPath(id string) string {
+	return signRequestStoragePath + "/" + id
+}
+
+func getSignRequest(ctx context.Context, s logical.Storage, id string) (*kmsSignRequest, error) {
+	entry, err := s.Get(ctx, getSignRequestPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("error reading sign request: %w", err)
+	}
+
+	if entry == nil {
+		return nil, fmt.Errorf("sign request not found: %v", id)
+	}
+
+	signReq := new(kmsSignRequest)
+	if err := entry.DecodeJSON(signReq); err != nil {
+		return nil, fmt.Errorf("error decode sign request: %w", err)
+	}
+
+	// A pending request past its TTL is reported expired without being rewritten.
+	if signReq.Status == signRequestPending && time.Now().After(signReq.ExpiresAt) {
+		signReq.Status = signRequestExpired
+	}
+
+	return signReq, nil
+}
+
+func putSignRequest(ctx context.Context, s logical.Storage, signReq *kmsSignRequest) error {
+	entry, err := logical.StorageEntryJSON(getSignRequestPath(signReq.ID), signReq)
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+func (b *kmsBackend) pathSignRequestCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.EntityID == "" {
+		return nil, fmt.Errorf("sign request requires a token with an entity")
+	}
+
+	sr, err := signRequestFields(d)
+	if err != nil {
+		return nil, err
+	}
+	if sr.Tx == nil && sr.TxSerialized == nil && sr.MsgHash == nil {
+		return nil, fmt.Errorf("missing tx, txSerialized or msgHash in sign")
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the request against the policy now, so that approvers are
+	// only asked for requests that can be signed.
+	session := newSignSession(b, config)
+	wallet, chainName, err := session.wallet(ctx, req, sr.Username, sr.Address, sr.ChainName)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := session.policy(ctx, req, sr.Username, sr.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.requiresApproval() {
+		return nil, fmt.Errorf("wallet does not require approval, sign with wallet/sign")
+	}
+
+	if sr.Tx != nil {
+		signer, err := newTxSigner(chainName, wallet, config, sr.Tx)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.checkTx(signer.summary()); err != nil {
+			return nil, err
+		}
+	} else if sr.TxSerialized != nil {
+		if err := policy.checkTx(nil); err != nil {
+			return nil, err
+		}
+	} else if err := policy.checkMsgHash(); err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	signReq := &kmsSignRequest{
+		ID:                id,
+		Request:           sr,
+		Status:            signRequestPending,
+		RequestedBy:       req.EntityID,
+		Approvers:         policy.Approvers,
+		RequiredApprovals: policy.RequiredApprovals,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(policy.approvalTTL()),
+	}
+
+	if err := putSignRequest(ctx, req.Storage, signReq); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: signReq.responseData(),
+	}, nil
+}
+
+func (b *kmsBackend) pathSignRequestList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ids, err := req.Storage.List(ctx, signRequestStoragePath+"/")
+	if err != nil {
+		return nil, err
+	}
+	ids = paginateKeys(ids, d.Get("after").(string), d.Get("limit").(int))
+
+	keyInfo := map[string]interface{}{}
+	for _, id := range ids {
+		signReq, err := getSignRequest(ctx, req.Storage, id)
+		if err != nil {
+			return nil, err
+		}
+
+		keyInfo[id] = map[string]interface{}{
+			"status":     signReq.Status,
+			"username":   signReq.Request.Username,
+			"address":    signReq.Request.Address,
+			"expires_at": signReq.ExpiresAt,
+		}
+	}
+
+	return logical.ListResponseWithInfo(ids, keyInfo), nil
+}
+
+func (b *kmsBackend) pathSignRequestRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	signReq, err := getSignRequest(ctx, req.Storage, d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: signReq.responseData(),
+	}, nil
+}
+
+func (b *kmsBackend) pathSignRequestDecide(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if req.EntityID == "" {
+		return nil, fmt.Errorf("approval requires a token with an entity")
+	}
+
+	b.signRequestLock.Lock()
+	defer b.signRequestLock.Unlock()
+
+	signReq, err := getSignRequest(ctx, req.Storage, d.Get("id").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	if signReq.Status != signRequestPending {
+		return nil, fmt.Errorf("sign request is %v", signReq.Status)
+	}
+	if !containsFold(signReq.Approvers, req.EntityID) {
+		return nil, fmt.Errorf("entity %v is not an approver of the sign request", req.EntityID)
+	}
+	if req.EntityID == signReq.RequestedBy {
+		return nil, fmt.Errorf("the requester cannot approve its own sign request")
+	}
+	if containsFold(signReq.Approvals, req.EntityID) || containsFold(signReq.Rejections, req.EntityID) {
+		return nil, fmt.Errorf("entity %v already decided on the sign request", req.EntityID)
+	}
+
+	if d.Get("decision").(string) == "approve" {
+		signReq.Approvals = append(signReq.Approvals, req.EntityID)
+	} else {
+		signReq.Rejections = append(signReq.Rejections, req.EntityID)
+	}
+
+	if len(signReq.Approvals) >= signReq.RequiredApprovals {
+		config, err := b.getConfig(ctx, req.Storage)
+		if err != nil {
+			return nil, err
+		}
+
+		sr := *signReq.Request
+		sr.approved = true
+		sr.requestedBy = signReq.RequestedBy
+		if signReq.Result, err = newSignSession(b, config).sign(ctx, req, &sr); err != nil {
+			signReq.Status = signRequestFailed
+			signReq.Error = err.Error()
+		} else {
+			signReq.Status = signRequestSigned
+		}
+	} else if len(signReq.Approvers)-len(signReq.Rejections) < signReq.RequiredApprovals {
+		// The remaining approvers can no longer reach the quorum.
+		signReq.Status = signRequestRejected
+	}
+
+	if err := putSignRequest(ctx, req.Storage, signReq); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: signReq.responseData(),
+	}, nil
+}
+
+// purgeSignRequests deletes the sign requests past their expiry by more than
+// signRequestRetention, whether they were decided or left pending.
+func (b *kmsBackend) purgeSignRequests(ctx context.Context, s logical.Storage) error {
+	b.signRequestLock.Lock()
+	defer b.signRequestLock.Unlock()
+
+	ids, err := s.List(ctx, signRequestStoragePath+"/")
+	if err != nil {
+		return fmt.Errorf("error listing sign requests: %w", err)
+	}
+
+	now := time.Now()
+	for _, id := range ids {
+		signReq, err := getSignRequest(ctx, s, id)
+		if err != nil {
+			b.Logger().Error("error reading sign request to purge", "id", id, "error", err)
+			continue
+		}
+		if now.Before(signReq.ExpiresAt.Add(signRequestRetention)) {
+			continue
+		}
+
+		if err := s.Delete(ctx, getSignRequestPath(id)); err != nil {
+			b.Logger().Error("error deleting sign request", "id", id, "error", err)
+			continue
+		}
+		b.Logger().Info("deleted sign request", "id", id, "status", signReq.Status)
+	}
+
+	return nil
+}
+
+func (r *kmsSignRequest) responseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"id":                 r.ID,
+		"status":             r.Status,
+		"username":           r.Request.Username,
+		"address":            r.Request.Address,
+		"requested_by":       r.RequestedBy,
+		"approvers":          r.Approvers,
+		"required_approvals": r.RequiredApprovals,
+		"approvals":          r.Approvals,
+		"rejections":         r.Rejections,
+		"created_at":         r.CreatedAt,
+		"expires_at":         r.ExpiresAt,
+	}
+	if r.Result != nil {
+		data["result"] = r.Result
+	}
+	if r.Error != "" {
+		data["error"] = r.Error
+	}
+	return data
+}
+
+const (
+	pathSignRequestHelpSynopsis    = `Manages the sign requests of wallets requiring approvals.`
+	pathSignRequestHelpDescription = `
+This path lets you submit a signing request for a wallet whose policy requires
+M-of-N approvals, with the same fields as wallet/sign. The request is stored
+as pending and its ID is returned.
+The approvers of the policy, identified by their Vault entity ID, approve or
+reject it with wallet/sign/request/<id>/approve and wallet/sign/request/<id>/reject.
+Once the required approvals are reached, the signature is produced and can be
+read at wallet/sign/request/<id>. Pending requests expire after the approvalTtl
+of the policy, and every request is deleted a day after it expires.
+List wallet/sign/request/ with after and limit to page through the requests.
+`
+)
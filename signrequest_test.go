@@ -0,0 +1,177 @@
+package kms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRequest(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+		"username":  username,
+		"chainName": "ether",
+	})
+	require.NoError(t, err)
+	walletAddress := resp.Data["address"].(string)
+
+	_, err = testPolicyRequest(t, b, reqStorage, logical.CreateOperation, map[string]interface{}{
+		"username":          username,
+		"address":           walletAddress,
+		"approvers":         "entity-a,entity-b,entity-c",
+		"requiredApprovals": 2,
+	})
+	require.NoError(t, err)
+
+	signData := map[string]interface{}{
+		"username": username,
+		"address":  walletAddress,
+		"tx": map[string]interface{}{
+			"chainId":  "1",
+			"nonce":    "0x0",
+			"gasPrice": "0x3b9aca00",
+			"gas":      "0x5208",
+			"to":       "0x3535353535353535353535353535353535353535",
+			"value":    "1000",
+		},
+	}
+
+	t.Run("Test Approve", func(t *testing.T) {
+		_, err := testSignCreate(t, b, reqStorage, signData)
+		require.ErrorContains(t, err, "requires 2 approvals")
+
+		_, err = testSignTypedData(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"address":   walletAddress,
+			"typedData": testTypedData,
+		})
+		require.ErrorContains(t, err, "requires 2 approvals, which typed data signing does not support")
+
+		_, err = testSignMessage(t, b, reqStorage, map[string]interface{}{
+			"username": username,
+			"address":  walletAddress,
+			"message":  "Sign in to example.com",
+		})
+		require.ErrorContains(t, err, "requires 2 approvals, which message signing does not support")
+
+		resp, err := testSignRequest(t, b, reqStorage, logical.CreateOperation, "wallet/sign/request", "entity-a", signData)
+		require.NoError(t, err)
+		require.Equal(t, signRequestPending, resp.Data["status"])
+		id := resp.Data["id"].(string)
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.ListOperation, "wallet/sign/request/", "entity-a", nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{id}, resp.Data["keys"])
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/approve", "entity-a", nil)
+		require.ErrorContains(t, err, "cannot approve its own")
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/approve", "entity-x", nil)
+		require.ErrorContains(t, err, "not an approver")
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/approve", "entity-b", nil)
+		require.NoError(t, err)
+		require.Equal(t, signRequestPending, resp.Data["status"])
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/reject", "entity-b", nil)
+		require.ErrorContains(t, err, "already decided")
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/approve", "entity-c", nil)
+		require.NoError(t, err)
+		require.Equal(t, signRequestSigned, resp.Data["status"])
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.ReadOperation, "wallet/sign/request/"+id, "entity-a", nil)
+		require.NoError(t, err)
+		require.Equal(t, signRequestSigned, resp.Data["status"])
+		result := resp.Data["result"].(map[string]interface{})
+		require.Equal(t, "0x", result["raw_tx"].(string)[:2])
+		require.NotEmpty(t, result["signature"])
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/reject", "entity-a", nil)
+		require.ErrorContains(t, err, "sign request is signed")
+	})
+
+	t.Run("Test Reject", func(t *testing.T) {
+		resp, err := testSignRequest(t, b, reqStorage, logical.CreateOperation, "wallet/sign/request", "entity-x", signData)
+		require.NoError(t, err)
+		id := resp.Data["id"].(string)
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/reject", "entity-a", nil)
+		require.NoError(t, err)
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/reject", "entity-b", nil)
+		require.NoError(t, err)
+		require.Equal(t, signRequestRejected, resp.Data["status"])
+		require.NotContains(t, resp.Data, "result")
+	})
+
+	t.Run("Test Expire", func(t *testing.T) {
+		resp, err := testSignRequest(t, b, reqStorage, logical.CreateOperation, "wallet/sign/request", "entity-x", signData)
+		require.NoError(t, err)
+		id := resp.Data["id"].(string)
+
+		signReq, err := getSignRequest(context.Background(), reqStorage, id)
+		require.NoError(t, err)
+		signReq.ExpiresAt = time.Now().Add(-time.Second)
+		require.NoError(t, putSignRequest(context.Background(), reqStorage, signReq))
+
+		_, err = testSignRequest(t, b, reqStorage, logical.UpdateOperation, "wallet/sign/request/"+id+"/approve", "entity-a", nil)
+		require.ErrorContains(t, err, "sign request is expired")
+	})
+
+	t.Run("Test List And Purge", func(t *testing.T) {
+		resp, err := testSignRequest(t, b, reqStorage, logical.ListOperation, "wallet/sign/request/", "entity-a", nil)
+		require.NoError(t, err)
+		ids := resp.Data["keys"].([]string)
+		require.Len(t, ids, 3)
+
+		resp, err = testSignRequest(t, b, reqStorage, logical.ListOperation, "wallet/sign/request/", "entity-a", map[string]interface{}{
+			"after": ids[0],
+			"limit": 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ids[1:2], resp.Data["keys"])
+
+		require.NoError(t, b.periodicFunc(context.Background(), &logical.Request{Storage: reqStorage}))
+		resp, err = testSignRequest(t, b, reqStorage, logical.ListOperation, "wallet/sign/request/", "entity-a", nil)
+		require.NoError(t, err)
+		require.Len(t, resp.Data["keys"], 3)
+
+		signReq, err := getSignRequest(context.Background(), reqStorage, ids[0])
+		require.NoError(t, err)
+		signReq.ExpiresAt = time.Now().Add(-signRequestRetention - time.Second)
+		require.NoError(t, putSignRequest(context.Background(), reqStorage, signReq))
+
+		require.NoError(t, b.periodicFunc(context.Background(), &logical.Request{Storage: reqStorage}))
+		resp, err = testSignRequest(t, b, reqStorage, logical.ListOperation, "wallet/sign/request/", "entity-a", nil)
+		require.NoError(t, err)
+		require.Equal(t, ids[1:], resp.Data["keys"])
+
+		_, err = testSignRequest(t, b, reqStorage, logical.ReadOperation, "wallet/sign/request/"+ids[0], "entity-a", nil)
+		require.ErrorContains(t, err, "sign request not found")
+	})
+}
+
+func testSignRequest(t *testing.T, b logical.Backend, s logical.Storage, op logical.Operation, path string, entityID string, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   op,
+		ClientToken: token,
+		EntityID:    entityID,
+		Path:        path,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
@@ -0,0 +1,242 @@
+package kms
+
+import (
+	"context"
+	"crypto/ed25519"
+	b64 "encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/perme-io/vault-plugin-secrets-kms/chains"
+)
+
+func pathVerify(b *kmsBackend) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "wallet/verify",
+			Fields: map[string]*framework.FieldSchema{
+				"address": {
+					Type:        framework.TypeString,
+					Description: "address expected to have signed",
+					Required:    false,
+				},
+				"publicKey": {
+					Type:        framework.TypeString,
+					Description: "public key expected to have signed, expressed as a hex string",
+					Required:    false,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain, defaults to the configured default chain",
+					Required:    false,
+				},
+				"txSerialized": {
+					Type:        framework.TypeString,
+					Description: "serialized transaction data",
+					Required:    false,
+				},
+				"msgHash": {
+					Type:        framework.TypeString,
+					Description: "the 32-byte message hash that was signed, expressed as a hex string",
+					Required:    false,
+				},
+				"signature": {
+					Type:        framework.TypeString,
+					Description: "signature in the native format of the chain, expressed as a base64 or hex string",
+					Required:    true,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathVerify,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathVerify,
+				},
+			},
+			HelpSynopsis:    pathVerifyHelpSynopsis,
+			HelpDescription: pathVerifyHelpDescription,
+		},
+	}
+}
+
+// decodeSignature decodes a hex (optionally 0x-prefixed) or base64 signature.
+func decodeSignature(signature string) ([]byte, error) {
+	if sigBytes, err := hex.DecodeString(strings.TrimPrefix(signature, "0x")); err == nil {
+		return sigBytes, nil
+	}
+
+	sigBytes, err := b64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding")
+	}
+	return sigBytes, nil
+}
+
+func (b *kmsBackend) pathVerify(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	chainName, err := config.chainName(chains.ChainName(d.Get("chainName").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	if chains.ChainKeyType(chainName) == chains.KeyEd25519 {
+		return b.verifyEd25519(d)
+	}
+
+	var hashBytes []byte
+	if ts, ok := d.GetOk("txSerialized"); ok {
+		if !txSerializedSupported(chainName) {
+			return nil, fmt.Errorf("txSerialized verification is not supported for %v, give the msgHash instead", chainName)
+		}
+		hashBytes = txHash(chainName, ts.(string))
+	} else if mh, ok := d.GetOk("msgHash"); ok {
+		hashBytes, _ = hex.DecodeString(strings.TrimPrefix(mh.(string), "0x"))
+	} else {
+		return nil, fmt.Errorf("missing txSerialized or msgHash in verify")
+	}
+	if len(hashBytes) != 32 {
+		return nil, fmt.Errorf("invalid hash length")
+	}
+
+	signature, err := decodeSignature(d.Get("signature").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	var pubKey *secp256k1.PublicKey
+	if pk, ok := d.GetOk("publicKey"); ok {
+		pubKeyBytes, err := hex.DecodeString(strings.TrimPrefix(pk.(string), "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid publicKey in verify: %w", err)
+		}
+		if pubKey, err = secp256k1.ParsePubKey(pubKeyBytes); err != nil {
+			return nil, fmt.Errorf("invalid publicKey in verify: %w", err)
+		}
+	}
+
+	address := d.Get("address").(string)
+	if pubKey == nil && address == "" {
+		return nil, fmt.Errorf("missing address or publicKey in verify")
+	}
+
+	// Aergo signatures are not recoverable, but its addresses encode the public key.
+	if pubKey == nil && chainName == chains.AERGO {
+		if pubKey, err = chains.AergoAddressPublicKey(address); err != nil {
+			return nil, err
+		}
+	}
+
+	chain, err := chains.NewChain(chainName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"valid": false,
+		},
+	}
+
+	signer, err := chain.VerifySignature(hashBytes, signature, pubKey)
+	if err != nil {
+		b.Logger().Debug("signature not verified", "error", err)
+		return resp, nil
+	}
+
+	signerAddress := chain.GetPublicKeyAddress(chain.SerializePublicKey(signer))
+	resp.Data["address"] = signerAddress
+
+	valid := true
+	if pubKey != nil {
+		valid = valid && pubKey.IsEqual(signer)
+	}
+	if address != "" {
+		valid = valid && strings.EqualFold(address, signerAddress)
+	}
+	resp.Data["valid"] = valid
+
+	return resp, nil
+}
+
+// verifyEd25519 verifies an ed25519 signature, which signs the message
+// given in msgHash itself, against the publicKey or the address.
+func (b *kmsBackend) verifyEd25519(d *framework.FieldData) (*logical.Response, error) {
+	mh, ok := d.GetOk("msgHash")
+	if !ok {
+		return nil, fmt.Errorf("missing msgHash in verify")
+	}
+	message, err := hex.DecodeString(strings.TrimPrefix(mh.(string), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid msgHash in verify: %w", err)
+	}
+
+	// Solana signatures are base58, tried when the hex or base64 decoding fails.
+	signature := d.Get("signature").(string)
+	sigBytes, err := decodeSignature(signature)
+	if err != nil || len(sigBytes) != ed25519.SignatureSize {
+		if decoded := base58.Decode(signature); len(decoded) == ed25519.SignatureSize {
+			sigBytes = decoded
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	var pubKey ed25519.PublicKey
+	if pk, ok := d.GetOk("publicKey"); ok {
+		if pubKey, err = hex.DecodeString(strings.TrimPrefix(pk.(string), "0x")); err != nil || len(pubKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid publicKey in verify")
+		}
+	}
+
+	address := d.Get("address").(string)
+	if address != "" {
+		addressKey, err := chains.DecodeSolanaAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		if pubKey != nil && !pubKey.Equal(addressKey) {
+			return nil, fmt.Errorf("publicKey does not match address in verify")
+		}
+		pubKey = addressKey
+	}
+	if pubKey == nil {
+		return nil, fmt.Errorf("missing address or publicKey in verify")
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"valid": false,
+		},
+	}
+
+	if err := chains.VerifyEd25519(message, sigBytes, pubKey); err != nil {
+		b.Logger().Debug("signature not verified", "error", err)
+		return resp, nil
+	}
+
+	resp.Data["address"] = base58.Encode(pubKey)
+	resp.Data["valid"] = true
+	return resp, nil
+}
+
+const (
+	pathVerifyHelpSynopsis    = `Verifies a signature produced for a wallet.`
+	pathVerifyHelpDescription = `
+This path lets you check a signature in the native format of the chain.
+Provide the signed txSerialized (or msgHash), the signature and the expected
+address or publicKey. The response tells whether the signature is valid
+and the address which produced it.
+Ed25519 signatures, such as Solana ones, sign the message itself, given
+in msgHash as a hex string, and may be given in base58.
+`
+)
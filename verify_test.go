@@ -0,0 +1,79 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVerify mocks the verification of signatures for kms.
+func TestVerify(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test Verify", func(t *testing.T) {
+		resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "icon",
+		})
+		require.NoError(t, err)
+
+		walletAddress := resp.Data["address"].(string)
+		txSerialized := "icx_sendTransaction.from." + walletAddress + ".nid.0x7.nonce.0x1"
+
+		resp, err = testSignCreate(t, b, reqStorage, map[string]interface{}{
+			"username":     username,
+			"address":      walletAddress,
+			"txSerialized": txSerialized,
+		})
+		require.NoError(t, err)
+
+		signature := resp.Data["signature"].(string)
+
+		resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+			"address":      walletAddress,
+			"chainName":    "icon",
+			"txSerialized": txSerialized,
+			"signature":    signature,
+		})
+		require.NoError(t, err)
+		require.Equal(t, true, resp.Data["valid"])
+		require.Equal(t, walletAddress, resp.Data["address"])
+
+		resp, err = testVerify(t, b, reqStorage, map[string]interface{}{
+			"address":      walletAddress,
+			"chainName":    "icon",
+			"txSerialized": txSerialized + ".version.0x3",
+			"signature":    signature,
+		})
+		require.NoError(t, err)
+		require.Equal(t, false, resp.Data["valid"])
+
+		_, err = testVerify(t, b, reqStorage, map[string]interface{}{
+			"chainName":    "icon",
+			"txSerialized": txSerialized,
+			"signature":    signature,
+		})
+		require.ErrorContains(t, err, "missing address or publicKey")
+	})
+}
+
+func testVerify(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.UpdateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/verify",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
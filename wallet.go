@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/btcsuite/btcutil/base58"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -12,17 +16,98 @@ import (
 )
 
 const (
-	walletStoragePath = "wallet"
+	walletStoragePath  = "wallet"
+	addressStoragePath = "address"
+
+	// walletSchemaVersion is the current layout of kmsWallet.
+	// Wallets stored before the chain was recorded have version 0.
+	walletSchemaVersion = 1
+
+	keyAlgorithmSecp256k1 = string(chains.KeySecp256k1)
 )
 
 type kmsWallet struct {
-	PrivateKey string `json:"private_key"`
-	PublicKey  string `json:"public_key"`
-	Address    string `json:"address"`
+	PrivateKey   string           `json:"private_key"`
+	PublicKey    string           `json:"public_key"`
+	Address      string           `json:"address"`
+	ChainName    chains.ChainName `json:"chain_name"`
+	CreatedAt    time.Time        `json:"created_at"`
+	KeyAlgorithm string           `json:"key_algorithm"`
+	Version      int              `json:"version"`
+
+	// Bitcoin wallets derive their address for a network and an address type.
+	Network     string `json:"network,omitempty"`
+	AddressType string `json:"address_type,omitempty"`
+	// Cosmos wallets derive their address with the bech32 prefix of the chain.
+	HRP string `json:"hrp,omitempty"`
+
+	// HD wallets store the seed location and derivation path instead of the private key.
+	SeedPath       string `json:"seed_path,omitempty"`
+	DerivationPath string `json:"derivation_path,omitempty"`
+
+	// Rotated wallets are retired and link to the wallet replacing them.
+	Status      string     `json:"status,omitempty"`
+	KeyVersion  int        `json:"key_version,omitempty"`
+	RetiredAt   *time.Time `json:"retired_at,omitempty"`
+	Predecessor string     `json:"predecessor,omitempty"`
+	Successor   string     `json:"successor,omitempty"`
+
+	// Deleted wallets are kept until DestroyAt, see wallet/restore.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DestroyAt *time.Time `json:"destroy_at,omitempty"`
 }
 
 func pathWallet(b *kmsBackend) []*framework.Path {
 	return []*framework.Path{
+		{
+			Pattern: "wallet/$",
+			Fields: map[string]*framework.FieldSchema{
+				"after": {
+					Type:        framework.TypeString,
+					Description: "list usernames sorted after this value",
+					Required:    false,
+				},
+				"limit": {
+					Type:        framework.TypeInt,
+					Description: "maximum number of usernames to list",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathWalletUserList,
+				},
+			},
+			HelpSynopsis:    pathWalletListHelpSynopsis,
+			HelpDescription: pathWalletListHelpDescription,
+		},
+		{
+			Pattern: "wallet/(?P<username>[^/]+)/$",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"after": {
+					Type:        framework.TypeString,
+					Description: "list addresses sorted after this value",
+					Required:    false,
+				},
+				"limit": {
+					Type:        framework.TypeInt,
+					Description: "maximum number of addresses to list",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.pathWalletAddressList,
+				},
+			},
+			HelpSynopsis:    pathWalletListHelpSynopsis,
+			HelpDescription: pathWalletListHelpDescription,
+		},
 		{
 			Pattern: "wallet",
 			Fields: map[string]*framework.FieldSchema{
@@ -41,6 +126,26 @@ func pathWallet(b *kmsBackend) []*framework.Path {
 					Description: "name of blockchain",
 					Required:    false,
 				},
+				"hd": {
+					Type:        framework.TypeBool,
+					Description: "derive the wallet from the HD seed of the user, or of the mount",
+					Required:    false,
+				},
+				"network": {
+					Type:        framework.TypeString,
+					Description: "bitcoin network of the wallet, mainnet, testnet or regtest",
+					Required:    false,
+				},
+				"addressType": {
+					Type:        framework.TypeString,
+					Description: "bitcoin address type of the wallet, p2pkh, p2wpkh or p2tr",
+					Required:    false,
+				},
+				"hrp": {
+					Type:        framework.TypeString,
+					Description: "bech32 prefix of the cosmos wallet address, such as cosmos or osmo",
+					Required:    false,
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
@@ -59,6 +164,61 @@ func pathWallet(b *kmsBackend) []*framework.Path {
 			HelpSynopsis:    pathWalletHelpSynopsis,
 			HelpDescription: pathWalletHelpDescription,
 		},
+		{
+			Pattern: "wallet/import",
+			Fields: map[string]*framework.FieldSchema{
+				"username": {
+					Type:        framework.TypeString,
+					Description: "username of wallet",
+					Required:    true,
+				},
+				"chainName": {
+					Type:        framework.TypeString,
+					Description: "name of blockchain, defaults to the configured default chain",
+					Required:    false,
+				},
+				"privateKey": {
+					Type:        framework.TypeString,
+					Description: "private key to import, expressed as a hex string, or base58 for ed25519 keys",
+					Required:    false,
+				},
+				"keystore": {
+					Type:        framework.TypeString,
+					Description: "ICON or Ethereum V3 keystore JSON holding the private key to import",
+					Required:    false,
+				},
+				"password": {
+					Type:        framework.TypeString,
+					Description: "password of the keystore",
+					Required:    false,
+				},
+				"network": {
+					Type:        framework.TypeString,
+					Description: "bitcoin network of the wallet, mainnet, testnet or regtest",
+					Required:    false,
+				},
+				"addressType": {
+					Type:        framework.TypeString,
+					Description: "bitcoin address type of the wallet, p2pkh, p2wpkh or p2tr",
+					Required:    false,
+				},
+				"hrp": {
+					Type:        framework.TypeString,
+					Description: "bech32 prefix of the cosmos wallet address, such as cosmos or osmo",
+					Required:    false,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: b.pathWalletImport,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.pathWalletImport,
+				},
+			},
+			HelpSynopsis:    pathWalletImportHelpSynopsis,
+			HelpDescription: pathWalletImportHelpDescription,
+		},
 	}
 }
 
@@ -67,6 +227,23 @@ func getWalletPath(username string, address string) string {
 	return walletStoragePath + "/" + userPath
 }
 
+// paginateKeys returns the sorted keys placed after the given key,
+// capped at limit entries when limit is positive.
+func paginateKeys(keys []string, after string, limit int) []string {
+	sort.Strings(keys)
+
+	start := sort.SearchStrings(keys, after)
+	for start < len(keys) && keys[start] <= after {
+		start++
+	}
+	keys = keys[start:]
+
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 func getWallet(ctx context.Context, req *logical.Request, walletPath string) (*kmsWallet, error) {
 	// Decode the data
 	entry, err := req.Storage.Get(ctx, walletPath)
@@ -83,9 +260,104 @@ func getWallet(ctx context.Context, req *logical.Request, walletPath string) (*k
 		return nil, fmt.Errorf("error decode wallet: %w", err)
 	}
 
+	if wallet.KeyAlgorithm == "" {
+		wallet.KeyAlgorithm = keyAlgorithmSecp256k1
+	}
+	if wallet.Status == "" {
+		wallet.Status = walletStatusActive
+	}
+	if wallet.KeyVersion == 0 {
+		wallet.KeyVersion = 1
+	}
+
 	return wallet, nil
 }
 
+// kmsAddressOwner indexes the user owning a wallet address.
+type kmsAddressOwner struct {
+	Username string `json:"username"`
+}
+
+func getAddressPath(address string) string {
+	return addressStoragePath + "/" + address
+}
+
+// putWallet stores the wallet of the user and indexes its address,
+// which must not be owned by another user.
+func putWallet(ctx context.Context, s logical.Storage, username string, wallet *kmsWallet) error {
+	if owner, err := getAddressOwner(ctx, s, wallet.Address); err != nil {
+		return err
+	} else if owner != nil && owner.Username != username {
+		return fmt.Errorf("address %v is owned by another user", wallet.Address)
+	}
+
+	entry, err := logical.StorageEntryJSON(getWalletPath(username, wallet.Address), wallet)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Put(ctx, entry); err != nil {
+		return err
+	}
+
+	entry, err = logical.StorageEntryJSON(getAddressPath(wallet.Address), &kmsAddressOwner{
+		Username: username,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Put(ctx, entry)
+}
+
+// getAddressOwner returns the user owning the address, or nil when no
+// wallet of the mount has it.
+func getAddressOwner(ctx context.Context, s logical.Storage, address string) (*kmsAddressOwner, error) {
+	entry, err := s.Get(ctx, getAddressPath(address))
+	if err != nil {
+		return nil, fmt.Errorf("error reading address: %w", err)
+	}
+
+	if entry == nil {
+		return nil, nil
+	}
+
+	owner := new(kmsAddressOwner)
+	if err := entry.DecodeJSON(owner); err != nil {
+		return nil, fmt.Errorf("error decode address: %w", err)
+	}
+
+	return owner, nil
+}
+
+// deleteAddressOwner drops the address index if it points to the user.
+func deleteAddressOwner(ctx context.Context, s logical.Storage, username string, address string) error {
+	owner, err := getAddressOwner(ctx, s, address)
+	if err != nil || owner == nil || owner.Username != username {
+		return err
+	}
+
+	if err := s.Delete(ctx, getAddressPath(address)); err != nil {
+		return fmt.Errorf("error deleting address: %w", err)
+	}
+	return nil
+}
+
+// resolveChainName returns the chain the wallet signs for. The requested
+// chain must match the stored one, and defaults to it when omitted.
+// Wallets created before the chain was recorded require a requested chain.
+func (w *kmsWallet) resolveChainName(requested chains.ChainName) (chains.ChainName, error) {
+	switch {
+	case w.ChainName == "" && requested == "":
+		return "", fmt.Errorf("missing chainName for wallet %v", w.Address)
+	case w.ChainName == "":
+		return requested, nil
+	case requested == "" || requested == w.ChainName:
+		return w.ChainName, nil
+	}
+	return "", fmt.Errorf("chainName mismatch: wallet=%v, requested=%v", w.ChainName, requested)
+}
+
 func (b *kmsBackend) pathWalletRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	var username string
 	if un, ok := d.GetOk("username"); ok {
@@ -111,34 +383,157 @@ func (b *kmsBackend) pathWalletRead(ctx context.Context, req *logical.Request, d
 	// Generate the response
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"address":    wallet.Address,
-			"public_key": wallet.PublicKey,
+			"address":       wallet.Address,
+			"public_key":    wallet.PublicKey,
+			"chain_name":    string(wallet.ChainName),
+			"created_at":    wallet.CreatedAt,
+			"key_algorithm": wallet.KeyAlgorithm,
+			"version":       wallet.Version,
+			"status":        wallet.Status,
 		},
 	}
+	if wallet.DerivationPath != "" {
+		resp.Data["derivation_path"] = wallet.DerivationPath
+	}
+	if wallet.ChainName == chains.BITCOIN {
+		if err := bitcoinWalletData(wallet, resp.Data); err != nil {
+			return nil, err
+		}
+	}
+	if wallet.HRP != "" {
+		resp.Data["hrp"] = wallet.HRP
+	}
+	if wallet.ChainName == chains.TRON {
+		if resp.Data["hex_address"], err = chains.TronAddressToHex(wallet.Address); err != nil {
+			return nil, err
+		}
+	}
+	if wallet.Successor != "" {
+		resp.Data["successor"] = wallet.Successor
+	}
+	if wallet.Predecessor != "" {
+		resp.Data["predecessor"] = wallet.Predecessor
+	}
+
+	if resp.Data["history"], err = walletHistory(ctx, req, username, wallet); err != nil {
+		return nil, err
+	}
 
 	return resp, nil
 }
 
 func createWallet(chainName chains.ChainName) (*kmsWallet, error) {
-	var chain chains.Chain
+	privateKey, err := chains.GeneratePrivateKey(chains.ChainKeyType(chainName))
+	if err != nil {
+		return nil, err
+	}
 
-	if privateKey, err := secp256k1.GeneratePrivateKey(); err == nil {
-		chain, err = chains.NewChain(chainName, privateKey)
-		if err != nil {
-			return nil, err
-		}
-	} else {
+	return newWallet(chainName, privateKey)
+}
+
+func newWallet(chainName chains.ChainName, privateKey chains.PrivateKey) (*kmsWallet, error) {
+	chain, err := chains.NewSigner(chainName, privateKey)
+	if err != nil {
 		return nil, err
 	}
 
 	pubKeySerialized := chain.GetPublicKeySerialized()
 	return &kmsWallet{
-		PrivateKey: hex.EncodeToString(chain.GetPrivateKeySerialized()),
-		PublicKey:  hex.EncodeToString(pubKeySerialized),
-		Address:    chain.GetPublicKeyAddress(pubKeySerialized),
+		PrivateKey:   hex.EncodeToString(chain.GetPrivateKeySerialized()),
+		PublicKey:    hex.EncodeToString(pubKeySerialized),
+		Address:      chain.GetPublicKeyAddress(pubKeySerialized),
+		ChainName:    chainName,
+		CreatedAt:    time.Now().UTC(),
+		KeyAlgorithm: string(chains.KeyTypeOf(privateKey)),
+		Version:      walletSchemaVersion,
 	}, nil
 }
 
+// setAddressOptions derives the address of the wallet for the address options
+// of the request: the network and address type of a bitcoin wallet, mainnet P2WPKH
+// by default, and the bech32 prefix of a cosmos wallet, cosmos by default.
+func (w *kmsWallet) setAddressOptions(chainName chains.ChainName, d *framework.FieldData) error {
+	network, addressType, hrp := d.Get("network").(string), d.Get("addressType").(string), d.Get("hrp").(string)
+
+	switch chainName {
+	case chains.BITCOIN:
+		if hrp != "" {
+			return fmt.Errorf("hrp is only supported for %v", chains.COSMOS)
+		}
+		if w.Network = network; w.Network == "" {
+			w.Network = chains.BitcoinMainnet
+		}
+		if w.AddressType = addressType; w.AddressType == "" {
+			w.AddressType = chains.BitcoinP2WPKH
+		}
+	case chains.COSMOS:
+		if network != "" || addressType != "" {
+			return fmt.Errorf("network and addressType are only supported for %v", chains.BITCOIN)
+		}
+		if w.HRP = hrp; w.HRP == "" {
+			w.HRP = chains.CosmosDefaultHRP
+		}
+	default:
+		if network != "" || addressType != "" || hrp != "" {
+			return fmt.Errorf("address options are not supported for %v", chainName)
+		}
+		return nil
+	}
+
+	return w.deriveAddress()
+}
+
+// deriveAddress derives the address of a wallet with address options from its public key.
+func (w *kmsWallet) deriveAddress() error {
+	pubKey, err := w.publicKey()
+	if err != nil {
+		return err
+	}
+
+	switch w.ChainName {
+	case chains.BITCOIN:
+		w.Address, err = chains.BitcoinAddress(pubKey, w.Network, w.AddressType)
+	case chains.COSMOS:
+		w.Address, err = chains.CosmosAddress(pubKey, w.HRP)
+	}
+	return err
+}
+
+func (w *kmsWallet) publicKey() (*secp256k1.PublicKey, error) {
+	pubKeyBytes, err := hex.DecodeString(w.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decode public key: %w", err)
+	}
+	pubKey, err := secp256k1.ParsePubKey(pubKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error decode public key: %w", err)
+	}
+	return pubKey, nil
+}
+
+// bitcoinWalletData adds the network, the address type and the address of
+// every address type of a bitcoin wallet to the response data.
+func bitcoinWalletData(wallet *kmsWallet, data map[string]interface{}) error {
+	pubKey, err := wallet.publicKey()
+	if err != nil {
+		return err
+	}
+
+	network := wallet.Network
+	if network == "" {
+		network = chains.BitcoinMainnet
+	}
+	addresses, err := chains.BitcoinAddresses(pubKey, network)
+	if err != nil {
+		return err
+	}
+
+	data["network"] = network
+	data["address_type"] = wallet.AddressType
+	data["addresses"] = addresses
+	return nil
+}
+
 func (b *kmsBackend) pathWalletCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	var username string
 	if un, ok := d.GetOk("username"); ok {
@@ -149,26 +544,127 @@ func (b *kmsBackend) pathWalletCreate(ctx context.Context, req *logical.Request,
 		return nil, fmt.Errorf("missing username in wallet")
 	}
 
-	var chainName chains.ChainName
-	if wtype, ok := d.GetOk("chainName"); ok {
-		chainName = chains.ChainName(wtype.(string))
-	} else {
-		return nil, fmt.Errorf("missing chainName in wallet")
+	config, err := b.getConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	chainName, err := config.chainName(chains.ChainName(d.Get("chainName").(string)))
+	if err != nil {
+		return nil, err
 	}
 	b.Logger().Debug("chainName:", chainName)
 
-	wallet, err := createWallet(chainName)
+	var wallet *kmsWallet
+	if d.Get("hd").(bool) {
+		wallet, err = b.createHDWallet(ctx, req, username, chainName)
+	} else {
+		wallet, err = createWallet(chainName)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create wallet. err=%v", err)
 	}
+	if err := wallet.setAddressOptions(chainName, d); err != nil {
+		return nil, err
+	}
 
-	walletPath := getWalletPath(username, wallet.Address)
-	entry, err := logical.StorageEntryJSON(walletPath, wallet)
+	if err := putWallet(ctx, req.Storage, username, wallet); err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"address": wallet.Address,
+		},
+	}
+
+	return resp, nil
+}
+
+// decodePrivateKey decodes a hex private key. Ed25519 keys are also
+// accepted in base58, as Solana wallets export them.
+func decodePrivateKey(keyType chains.KeyType, privateKey string) ([]byte, error) {
+	privKeyBytes, err := hex.DecodeString(strings.TrimPrefix(privateKey, "0x"))
+	if err == nil {
+		return privKeyBytes, nil
+	}
+
+	if keyType == chains.KeyEd25519 {
+		if privKeyBytes = base58.Decode(privateKey); len(privKeyBytes) > 0 {
+			return privKeyBytes, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid privateKey in wallet: %w", err)
+}
+
+func (b *kmsBackend) pathWalletImport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		if username = un.(string); username == "" {
+			return nil, fmt.Errorf("empty username in wallet")
+		}
+	} else {
+		return nil, fmt.Errorf("missing username in wallet")
+	}
+
+	config, err := b.getConfig(ctx, req.Storage)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := req.Storage.Put(ctx, entry); err != nil {
+	chainName, err := config.chainName(chains.ChainName(d.Get("chainName").(string)))
+	if err != nil {
+		return nil, err
+	}
+
+	keyType := chains.ChainKeyType(chainName)
+
+	var privKeyBytes []byte
+	var keystoreAddress string
+	if pk, ok := d.GetOk("privateKey"); ok {
+		var err error
+		if privKeyBytes, err = decodePrivateKey(keyType, pk.(string)); err != nil {
+			return nil, err
+		}
+	} else if ks, ok := d.GetOk("keystore"); ok {
+		if keyType != chains.KeySecp256k1 {
+			return nil, fmt.Errorf("keystore import is not supported for %v", chainName)
+		}
+
+		var err error
+		privKeyBytes, keystoreAddress, err = decryptKeystore([]byte(ks.(string)), d.Get("password").(string))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("missing privateKey or keystore in wallet")
+	}
+
+	privateKey, err := chains.ParsePrivateKey(keyType, privKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := newWallet(chainName, privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import wallet. err=%v", err)
+	}
+	if err := wallet.setAddressOptions(chainName, d); err != nil {
+		return nil, err
+	}
+
+	if !keystoreAddressMatches(keystoreAddress, wallet.Address) {
+		return nil, fmt.Errorf("keystore address mismatch: keystore=%v, derived=%v", keystoreAddress, wallet.Address)
+	}
+
+	walletPath := getWalletPath(username, wallet.Address)
+	if entry, err := req.Storage.Get(ctx, walletPath); err != nil {
+		return nil, fmt.Errorf("error reading wallet: %w", err)
+	} else if entry != nil {
+		return nil, fmt.Errorf("wallet already exists: %v", wallet.Address)
+	}
+
+	if err := putWallet(ctx, req.Storage, username, wallet); err != nil {
 		return nil, err
 	}
 
@@ -199,19 +695,140 @@ func (b *kmsBackend) pathWalletDelete(ctx context.Context, req *logical.Request,
 	}
 
 	walletPath := getWalletPath(username, address)
+	wallet, err := getWallet(ctx, req, walletPath)
+	if err != nil {
+		return nil, err
+	}
 
-	err := req.Storage.Delete(ctx, walletPath)
+	config, err := b.getConfig(ctx, req.Storage)
 	if err != nil {
+		return nil, err
+	}
+
+	// The wallet is kept under the deleted prefix until the retention
+	// window expires, with its policy and limits, so that it can be restored.
+	now := time.Now().UTC()
+	destroyAt := now.Add(config.DeletionRetention)
+	wallet.DeletedAt = &now
+	wallet.DestroyAt = &destroyAt
+
+	entry, err := logical.StorageEntryJSON(getDeletedWalletPath(username, address), wallet)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Delete(ctx, walletPath); err != nil {
 		return nil, fmt.Errorf("error deleting wallet: %w", err)
 	}
+	b.getKeyCache().remove(walletPath)
+
+	if err := deleteAddressOwner(ctx, req.Storage, username, address); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"deleted_at": now,
+			"destroy_at": destroyAt,
+		},
+	}, nil
+}
+
+func (b *kmsBackend) pathWalletUserList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, walletStoragePath+"/")
+	if err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+
+	usernames := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			usernames = append(usernames, strings.TrimSuffix(entry, "/"))
+		}
+	}
+
+	usernames = paginateKeys(usernames, d.Get("after").(string), d.Get("limit").(int))
 
-	return nil, nil
+	return logical.ListResponse(usernames), nil
 }
 
+func (b *kmsBackend) pathWalletAddressList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	var username string
+	if un, ok := d.GetOk("username"); ok {
+		if username = un.(string); username == "" {
+			return nil, fmt.Errorf("empty username in wallet")
+		}
+	} else {
+		return nil, fmt.Errorf("missing username in wallet")
+	}
+
+	entries, err := req.Storage.List(ctx, walletStoragePath+"/"+username+"/")
+	if err != nil {
+		return nil, fmt.Errorf("error listing wallets: %w", err)
+	}
+
+	addresses := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, "/") {
+			addresses = append(addresses, entry)
+		}
+	}
+
+	addresses = paginateKeys(addresses, d.Get("after").(string), d.Get("limit").(int))
+
+	keyInfo := make(map[string]interface{}, len(addresses))
+	for _, address := range addresses {
+		wallet, err := getWallet(ctx, req, getWalletPath(username, address))
+		if err != nil {
+			return nil, err
+		}
+
+		keyInfo[address] = map[string]interface{}{
+			"address":    wallet.Address,
+			"chain_name": string(wallet.ChainName),
+			"created_at": wallet.CreatedAt,
+			"status":     wallet.Status,
+		}
+	}
+
+	return logical.ListResponseWithInfo(addresses, keyInfo), nil
+}
+
+const (
+	pathWalletImportHelpSynopsis    = `Imports an existing private key into the Vault wallet.`
+	pathWalletImportHelpDescription = `
+This path lets you bring an existing key under the Vault.
+Provide either the privateKey field as a hex string, or the keystore field
+with an ICON or Ethereum V3 keystore JSON and its password.
+Solana wallets take the 32-byte ed25519 seed, or the 64-byte keypair in hex
+or base58 as Solana wallets export it.
+An already stored wallet of the user is never overwritten.
+`
+)
+
+const (
+	pathWalletListHelpSynopsis    = `Lists the wallets stored in the Vault.`
+	pathWalletListHelpDescription = `
+This path lets you list the usernames owning wallets with "wallet/",
+and the wallets of a user with "wallet/<username>/".
+Use the after and limit fields to page through large listings.
+`
+)
+
 const (
 	pathWalletHelpSynopsis    = `Manages the Vault wallet for generating transaction signature.`
 	pathWalletHelpDescription = `
 This path allows you to read and write wallet used to generate transaction signature.
 You can create wallet to generate a user's transaction signature by setting the username field.
+Deleting a wallet keeps it for the deletionRetention of the config, during which
+it can be restored with wallet/restore, before it is destroyed.
+Bitcoin wallets take the network (mainnet, testnet or regtest) and the addressType
+(p2pkh, p2wpkh or p2tr) their address is derived for, mainnet p2wpkh by default.
+Cosmos wallets take the bech32 prefix of the chain as hrp, cosmos by default.
+Solana wallets hold ed25519 keys, the other chains secp256k1 keys, as the
+key_algorithm of the wallet tells.
 `
 )
@@ -126,7 +126,8 @@ func TestCreateWallet(t *testing.T) {
 	}{
 		{chains.ICON, nil},
 		{chains.AERGO, nil},
-		{"solana", "unknown chain name"},
+		{chains.SOLANA, nil},
+		{"polkadot", "unknown chain name"},
 	}
 
 	for _, tc := range testCases {
@@ -136,7 +137,7 @@ func TestCreateWallet(t *testing.T) {
 			t.Logf("chainName=%v, error=%v", tc.chainName, err)
 
 			switch tc.chainName {
-			case chains.ICON, chains.AERGO:
+			case chains.ICON, chains.AERGO, chains.SOLANA:
 				require.Nilf(t, err, "createWallet err: expected nil, actual=%v", err)
 				require.NotNilf(t, wallet, "createWallet wallet: expected not nil, actual=%v", wallet)
 			default:
@@ -182,7 +183,10 @@ func TestWallet(t *testing.T) {
 			"address":  walletAddress,
 		}
 		expectedData := map[string]interface{}{
-			"address": walletAddress,
+			"address":       walletAddress,
+			"chain_name":    "icon",
+			"key_algorithm": keyAlgorithmSecp256k1,
+			"version":       walletSchemaVersion,
 		}
 		err = testWalletRead(t, b, reqStorage, reqData, expectedData)
 
@@ -294,7 +298,7 @@ func testWalletRead(t *testing.T, b logical.Backend, s logical.Storage, d map[st
 		return resp.Error()
 	}
 
-	if len(resp.Data) != 2 {
+	if len(resp.Data) != 8 {
 		return fmt.Errorf("read data mismatch (expected %d values, got %d)", len(expected), len(resp.Data))
 	}
 
@@ -329,3 +333,139 @@ func testWalletDelete(t *testing.T, b logical.Backend, s logical.Storage, d map[
 	}
 	return nil
 }
+
+// TestWalletList mocks the listing of users and their wallets for kms.
+func TestWalletList(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test Wallet List", func(t *testing.T) {
+		var addresses []string
+		for _, chainName := range []string{"icon", "aergo", "ether"} {
+			resp, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+				"username":  username,
+				"chainName": chainName,
+			})
+			require.NoError(t, err)
+			addresses = append(addresses, resp.Data["address"].(string))
+		}
+
+		_, err := testWalletCreate(t, b, reqStorage, map[string]interface{}{
+			"username":  "other-" + username,
+			"chainName": "icon",
+		})
+		require.NoError(t, err)
+
+		resp, err := testWalletList(t, b, reqStorage, walletStoragePath+"/", nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"other-" + username, username}, resp.Data["keys"])
+
+		resp, err = testWalletList(t, b, reqStorage, walletStoragePath+"/", map[string]interface{}{
+			"limit": 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"other-" + username}, resp.Data["keys"])
+
+		resp, err = testWalletList(t, b, reqStorage, walletStoragePath+"/"+username+"/", nil)
+		require.NoError(t, err)
+
+		keys := resp.Data["keys"].([]string)
+		require.ElementsMatch(t, addresses, keys)
+
+		keyInfo := resp.Data["key_info"].(map[string]interface{})
+		for _, address := range addresses {
+			info := keyInfo[address].(map[string]interface{})
+			require.Equal(t, address, info["address"])
+			require.NotEmpty(t, info["chain_name"])
+		}
+
+		resp, err = testWalletList(t, b, reqStorage, walletStoragePath+"/"+username+"/", map[string]interface{}{
+			"after": keys[0],
+			"limit": 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{keys[1]}, resp.Data["keys"])
+	})
+}
+
+func testWalletList(t *testing.T, b logical.Backend, s logical.Storage, path string, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.ListOperation,
+		ClientToken: token,
+		Path:        path,
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}
+
+// TestWalletImport mocks the import of existing keys for kms.
+func TestWalletImport(t *testing.T) {
+	b, reqStorage := getTestBackend(t)
+
+	t.Run("Test Wallet Import", func(t *testing.T) {
+		resp, err := testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   username,
+			"chainName":  "icon",
+			"privateKey": "1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "hx5443d0db003fd7202046bbf31eaeade60af20c41", resp.Data["address"])
+
+		_, err = testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   username,
+			"chainName":  "icon",
+			"privateKey": "1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c",
+		})
+		require.ErrorContains(t, err, "wallet already exists")
+
+		_, err = testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   "other",
+			"chainName":  "icon",
+			"privateKey": "1a5e70bfd427ec9ec3decf8d6e3461dfb4dbbde4351e071d9728d96e711e1b9c",
+		})
+		require.ErrorContains(t, err, "owned by another user")
+
+		resp, err = testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":  username,
+			"chainName": "ether",
+			"keystore":  testKeystoreJSON,
+			"password":  testKeystorePassword,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0x008aeeda4d805471df9b2a5b0f38a0c3bcba786b", resp.Data["address"])
+
+		_, err = testWalletImport(t, b, reqStorage, map[string]interface{}{
+			"username":   username,
+			"chainName":  "icon",
+			"privateKey": "00",
+		})
+		require.ErrorContains(t, err, "invalid privateKey length")
+	})
+}
+
+func testWalletImport(t *testing.T, b logical.Backend, s logical.Storage, d map[string]interface{}) (*logical.Response, error) {
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:   logical.CreateOperation,
+		ClientToken: token,
+		Path:        walletStoragePath + "/import",
+		Data:        d,
+		Storage:     s,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp != nil && resp.IsError() {
+		return nil, resp.Error()
+	}
+	return resp, nil
+}